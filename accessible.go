@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// terminalNoColor is whether colors were disabled before the accessible
+// mode, by the terminal or NO_COLOR.
+var terminalNoColor = color.NoColor
+
+// applyAccessibleMode disables the colors in accessible mode, and restores
+// them otherwise.
+func applyAccessibleMode() {
+	color.NoColor = terminalNoColor || config.Accessible
+}
+
+// getLanguageName returns the English name of a language, which screen
+// readers read better than its code.
+func getLanguageName(lang string) string {
+	name, ok := ponsLanguageNames[lang]
+	if !ok {
+		return strings.ToUpper(lang)
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// accessibleRenderer renders results as labeled lines, without tables nor
+// alignment padding, for screen readers.
+type accessibleRenderer struct{}
+
+func (accessibleRenderer) Render(w io.Writer, translations TranslationResponse, dict string, word string, opts renderOptions) {
+	clean := func(s string) string {
+		return strings.Join(strings.Fields(plainText(s)), " ")
+	}
+	line := func(source, target string) {
+		label := "source"
+		if isExample(source) {
+			label = "example"
+		}
+		fmt.Fprintf(w, "%s: %s, target: %s\n", label, clean(source), clean(target))
+	}
+
+	corrections := loadCorrections(dict)
+	for _, lang := range translations {
+		fmt.Fprintf(w, "%s to %s.\n", getLanguageName(lang.Lang), getLanguageName(getTargetLang(dict, lang.Lang)))
+		entry := 0
+		for _, hit := range lang.Hits {
+			if len(hit.Roms) == 0 {
+				line(hit.Source, hit.Target)
+				continue
+			}
+			for _, rom := range hit.Roms {
+				entry++
+				fmt.Fprintf(w, "entry %d: %s", entry, clean(rom.Headword))
+				if rom.WordClass != "" {
+					fmt.Fprintf(w, ", %s", rom.WordClass)
+				}
+				fmt.Fprintln(w)
+				if phonetics := getPhonetics(rom); phonetics != "" && config.Phonetics {
+					fmt.Fprintf(w, "pronunciation: %s\n", phonetics)
+				}
+				if frequency := plainText(formatFrequency(rom, lang.Lang)); frequency != "" {
+					fmt.Fprintf(w, "frequency: %s\n", strings.TrimSpace(frequency))
+				}
+				for _, arab := range rom.Arabs {
+					if header := clean(arab.Header); header != "" {
+						fmt.Fprintf(w, "sense: %s\n", header)
+					}
+					for _, translation := range arab.Translations {
+						line(translation.Source, translation.Target)
+					}
+				}
+				if correction, ok := corrections[normalizeHeadword(rom.Headword)]; ok {
+					fmt.Fprintf(w, "correction: %s\n", correction)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// heatmapWeeks is the number of weeks shown by the .stats heatmap.
+const heatmapWeeks = 26
+
+// recordActivity counts a lookup or a flashcard review in today's activity,
+// and tells when it reaches the daily goal.
+func recordActivity(lookups, reviews int) error {
+	day := time.Now().Format("2006-01-02")
+	_, err := db.Exec(`
+		INSERT INTO activity(day, lookups, reviews) VALUES(?, ?, ?)
+		ON CONFLICT(day) DO UPDATE SET lookups = lookups + excluded.lookups, reviews = reviews + excluded.reviews
+	`, day, lookups, reviews)
+	if err != nil {
+		return fmt.Errorf("could not record activity: %w", err)
+	}
+
+	if config.DailyGoal > 0 {
+		activity, err := getActivity()
+		if err != nil {
+			return err
+		}
+		if activity[day] == config.DailyGoal {
+			printNotice("Daily goal of %d reached!\n", config.DailyGoal)
+			runHooks(eventDailyGoal, fmt.Sprintf("Daily goal of %d lookups and reviews reached", config.DailyGoal))
+		}
+	}
+	return nil
+}
+
+// getActivity returns the number of lookups and reviews of each day.
+func getActivity() (map[string]int, error) {
+	rows, err := db.Query("SELECT day, lookups + reviews FROM activity")
+	if err != nil {
+		return nil, fmt.Errorf("could not query activity: %w", err)
+	}
+	defer rows.Close()
+
+	activity := map[string]int{}
+	for rows.Next() {
+		var day string
+		var count int
+		if err := rows.Scan(&day, &count); err != nil {
+			return nil, fmt.Errorf("could not scan row: %w", err)
+		}
+		activity[day] = count
+	}
+	return activity, rows.Err()
+}
+
+// isGoalReached tells whether the activity of a day counts in the streak.
+func isGoalReached(count int) bool {
+	return count > 0 && count >= config.DailyGoal
+}
+
+// getStreak returns the number of consecutive days the daily goal was
+// reached, up to today, or yesterday while today's goal can still be.
+func getStreak(activity map[string]int, today time.Time) int {
+	day := today
+	if !isGoalReached(activity[day.Format("2006-01-02")]) {
+		day = day.AddDate(0, 0, -1)
+	}
+	streak := 0
+	for isGoalReached(activity[day.Format("2006-01-02")]) {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+func printGoalProgress(activity map[string]int, today time.Time) {
+	count := activity[today.Format("2006-01-02")]
+	color.New(color.FgGreen).Print("Today: ")
+	if config.DailyGoal > 0 {
+		fmt.Printf("%d/%d lookups and reviews\n", count, config.DailyGoal)
+	} else {
+		fmt.Printf("%d lookups and reviews\n", count)
+	}
+	streak := getStreak(activity, today)
+	color.New(color.FgGreen).Print("Streak: ")
+	if streak == 1 {
+		fmt.Println("1 day")
+	} else {
+		fmt.Printf("%d days\n", streak)
+	}
+}
+
+func handleGoalCommand(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: .goal [<n>]")
+	}
+	if len(args) == 1 {
+		goal, err := strconv.Atoi(args[0])
+		if err != nil || goal < 0 {
+			return fmt.Errorf("invalid daily goal: %s", args[0])
+		}
+		config.DailyGoal = goal
+		if err := writeConfig(); err != nil {
+			return err
+		}
+	}
+
+	activity, err := getActivity()
+	if err != nil {
+		return err
+	}
+	printGoalProgress(activity, time.Now())
+	return nil
+}
+
+func handleStatsCommand(args []string) error {
+	if len(args) == 1 && args[0] == "network" {
+		return showNetworkStats()
+	}
+	if len(args) == 1 && args[0] == "reviews" {
+		return showReviewStats()
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("usage: .stats [network|reviews]")
+	}
+
+	activity, err := getActivity()
+	if err != nil {
+		return err
+	}
+	today := time.Now()
+	printGoalProgress(activity, today)
+
+	total, due, err := countFlashcards(today)
+	if err != nil {
+		return err
+	}
+	color.New(color.FgGreen).Print("Flashcards: ")
+	fmt.Printf("%d saved, %d due\n", total, due)
+
+	requests, err := getAPIRequests(today)
+	if err != nil {
+		return err
+	}
+	color.New(color.FgGreen).Print("API requests this month: ")
+	if config.MonthlyRequestBudget > 0 {
+		fmt.Printf("%d/%d\n", requests, config.MonthlyRequestBudget)
+	} else {
+		fmt.Println(requests)
+	}
+
+	fmt.Println()
+	printHeatmap(activity, today)
+	return nil
+}
+
+// printHeatmap prints the activity of the last weeks as a calendar, a
+// column per week and a row per day of the week.
+func printHeatmap(activity map[string]int, today time.Time) {
+	// Weeks start on Monday
+	offset := (int(today.Weekday()) + 6) % 7
+	start := today.AddDate(0, 0, -offset-7*(heatmapWeeks-1))
+
+	levels := []*color.Color{
+		color.New(color.Faint),
+		color.New(color.FgGreen, color.Faint),
+		color.New(color.FgGreen),
+		color.New(color.FgGreen, color.Bold),
+	}
+	level := func(count int) int {
+		switch {
+		case count == 0:
+			return 0
+		case isGoalReached(count):
+			return 3
+		case config.DailyGoal > 0 && count*2 >= config.DailyGoal:
+			return 2
+		}
+		return 1
+	}
+
+	// Label the first week of each month, each week taking two columns
+	months := []rune(strings.Repeat(" ", 2*heatmapWeeks+1))
+	free := 0
+	for week := 0; week < heatmapWeeks; week++ {
+		day := start.AddDate(0, 0, 7*week)
+		if (week == 0 || day.Day() <= 7) && 2*week >= free {
+			copy(months[2*week:], []rune(day.Format("Jan")))
+			free = 2*week + 4
+		}
+	}
+	fmt.Println("    " + strings.TrimRight(string(months), " "))
+
+	for weekday := 0; weekday < 7; weekday++ {
+		fmt.Print(start.AddDate(0, 0, weekday).Format("Mon")[:2] + "  ")
+		for week := 0; week < heatmapWeeks; week++ {
+			day := start.AddDate(0, 0, 7*week+weekday)
+			if day.After(today) {
+				break
+			}
+			count := activity[day.Format("2006-01-02")]
+			symbol := "■ "
+			if count == 0 {
+				symbol = "· "
+			}
+			levels[level(count)].Print(symbol)
+		}
+		fmt.Println()
+	}
+}
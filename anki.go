@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"log/slog"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+type ankiResponse struct {
+	Result any     `json:"result"`
+	Error  *string `json:"error"`
+}
+
+// ankiRequest calls an action of the AnkiConnect API of a running Anki.
+func ankiRequest(action string, params any) (any, error) {
+	payload := map[string]any{"action": action, "version": 6, "params": params}
+	var resp ankiResponse
+	if err := postJSON(config.AnkiURL, payload, nil, &resp); err != nil {
+		return nil, fmt.Errorf("could not reach AnkiConnect, is Anki running? %w", err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("AnkiConnect %s: %s", action, *resp.Error)
+	}
+	return resp.Result, nil
+}
+
+func handleAnkiCommand(args []string) error {
+	if len(args) < 1 || len(args) > 2 || args[0] != "push" {
+		return fmt.Errorf("usage: .anki push [<dict>]")
+	}
+	dict := currentDict
+	if len(args) == 2 {
+		dict = args[1]
+	}
+	if dict == "" {
+		return fmt.Errorf("no dictionary selected. Use .dict <key> to select one")
+	}
+	return pushToAnki(dict)
+}
+
+// pushToAnki creates a note for each word searched in dict, or updates it
+// when the deck already has one for that word.
+func pushToAnki(dict string) error {
+	terms, err := getSearchedTermsOf(dict)
+	if err != nil {
+		return err
+	}
+	if len(terms) == 0 {
+		return fmt.Errorf("no words searched in %s", dict)
+	}
+
+	if _, err := ankiRequest("createDeck", map[string]any{"deck": config.AnkiDeck}); err != nil {
+		return err
+	}
+
+	added, updated, failed := 0, 0, 0
+	for _, term := range terms {
+		translations, err := getTranslation(term, dict)
+		if err != nil {
+			// Log the error, but don't fail the command
+			slog.Warn("could not translate", "term", term, "err", err)
+			continue
+		}
+		back := getAnkiBack(translations)
+
+		// The front is saved escaped, and is searched as saved
+		front := html.EscapeString(term)
+		query := fmt.Sprintf(`deck:"%s" "Front:%s"`, config.AnkiDeck, escapeAnkiSearch(front))
+		result, err := ankiRequest("findNotes", map[string]any{"query": query})
+		if err != nil {
+			slog.Warn("could not find Anki note", "term", term, "err", err)
+			failed++
+			continue
+		}
+		if ids, _ := result.([]any); len(ids) > 0 {
+			note := map[string]any{"id": ids[0], "fields": map[string]string{"Back": back}}
+			if _, err := ankiRequest("updateNoteFields", map[string]any{"note": note}); err != nil {
+				slog.Warn("could not update Anki note", "term", term, "err", err)
+				failed++
+				continue
+			}
+			updated++
+			continue
+		}
+
+		note := map[string]any{
+			"deckName":  config.AnkiDeck,
+			"modelName": "Basic",
+			"fields":    map[string]string{"Front": front, "Back": back},
+			"options":   map[string]any{"allowDuplicate": false},
+			"tags":      []string{"pons-cli", dict},
+		}
+		if _, err := ankiRequest("addNote", map[string]any{"note": note}); err != nil {
+			slog.Warn("could not add Anki note", "term", term, "err", err)
+			failed++
+			continue
+		}
+		added++
+	}
+
+	color.New(color.FgGreen).Printf("%d notes added to %s, %d updated", added, config.AnkiDeck, updated)
+	if failed > 0 {
+		fmt.Printf(", %d failed, see the log", failed)
+	}
+	fmt.Println()
+	return nil
+}
+
+// escapeAnkiSearch escapes the text searched for in an Anki query, within
+// double quotes, so that its wildcards and colons match themselves.
+func escapeAnkiSearch(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `*`, `\*`, `_`, `\_`, `:`, `\:`).Replace(s)
+}
+
+// getAnkiBack returns the back of a note: the first translations of a
+// lookup result, one per line.
+func getAnkiBack(translations TranslationResponse) string {
+	var lines []string
+	for _, line := range getTranslationLines(translations, maxCardRows) {
+		lines = append(lines, strings.ReplaceAll(html.EscapeString(line), "\n", "<br>"))
+	}
+	return strings.Join(lines, "<br>")
+}
+
+// getSearchedTermsOf returns the words searched in dict, as they were typed.
+func getSearchedTermsOf(dict string) ([]string, error) {
+	rows, err := db.Query("SELECT DISTINCT searched_term FROM search_history WHERE dict = ? AND found = 1 ORDER BY searched_term", dict)
+	if err != nil {
+		return nil, fmt.Errorf("could not query search history: %w", err)
+	}
+	defer rows.Close()
+
+	var terms []string
+	for rows.Next() {
+		var term string
+		if err := rows.Scan(&term); err != nil {
+			return nil, fmt.Errorf("could not scan row: %w", err)
+		}
+		terms = append(terms, term)
+	}
+	return terms, rows.Err()
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBodySize bounds how much of an error response is read.
+const maxErrorBodySize = 4096
+
+// apiError is the error of a request answered with an unexpected status.
+type apiError struct {
+	StatusCode int
+	// Message is the explanation found in the response body, if any.
+	Message string
+	// PONS tells whether the PONS API answered, whose statuses come with
+	// guidance.
+	PONS bool
+}
+
+func (e *apiError) Error() string {
+	status := fmt.Sprintf("status %d", e.StatusCode)
+	if e.Message != "" {
+		status += ": " + e.Message
+	}
+	switch {
+	case e.PONS && (e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden):
+		return fmt.Sprintf("invalid or expired API key (%s), check it with .set api_key <key>", status)
+	case e.PONS && e.StatusCode == http.StatusNotFound:
+		return fmt.Sprintf("unknown dictionary (%s), list the available ones with .dict", status)
+	case e.PONS && e.StatusCode == http.StatusTooManyRequests:
+		return fmt.Sprintf("API quota exceeded (%s), the cache and the offline dictionaries still work", status)
+	case e.Message != "":
+		return fmt.Sprintf("bad status code: %d (%s)", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("bad status code: %d", e.StatusCode)
+}
+
+// newAPIError returns the error of a response with an unexpected status,
+// with the message of its body.
+func newAPIError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	return &apiError{
+		StatusCode: resp.StatusCode,
+		Message:    getErrorMessage(body),
+		PONS:       resp.Request != nil && strings.HasPrefix(resp.Request.URL.String(), baseURL),
+	}
+}
+
+// getErrorMessage extracts the message of an error response body, be it a
+// JSON object or a short text. HTML pages are ignored.
+func getErrorMessage(body []byte) string {
+	var object map[string]any
+	if json.Unmarshal(body, &object) == nil {
+		for _, key := range []string{"message", "error", "detail", "error_description"} {
+			if message, ok := object[key].(string); ok && message != "" {
+				return message
+			}
+		}
+		return ""
+	}
+
+	text := strings.Join(strings.Fields(string(body)), " ")
+	if strings.HasPrefix(text, "<") || len(text) > 200 {
+		return ""
+	}
+	return text
+}
+
+// getStatusCode returns the status of the response err comes from, or 0.
+func getStatusCode(err error) int {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// validateAPIKey makes a lookup with the API key, which fails if PONS
+// rejects the key.
+func validateAPIKey(key string) error {
+	req, err := http.NewRequest("GET", dictionaryURL, nil)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+	q := req.URL.Query()
+	q.Add("q", "a")
+	q.Add("l", "deen")
+	req.URL.RawQuery = q.Encode()
+	req.Header.Add("X-Secret", key)
+
+	resp, err := getHTTPClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("could not reach the PONS API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := countAPIRequest(); err != nil {
+		// Log the error, but don't fail the command
+		slog.Warn("could not count API request", "err", err)
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return newAPIError(resp)
+	}
+	return nil
+}
+
+// checkAPIKey tells whether the API key just set works, so that a mistyped
+// key doesn't go unnoticed until the next lookup.
+func checkAPIKey() error {
+	if overBudget, err := isBudgetExceeded(); err != nil || overBudget {
+		printNotice("API key saved, not validated as the monthly request budget is exceeded\n")
+		return nil
+	}
+	if err := validateAPIKey(config.APIKey); err != nil {
+		return fmt.Errorf("API key saved, but not validated: %w", err)
+	}
+	printNotice("API key saved and valid\n")
+	return nil
+}
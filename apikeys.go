@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiKeyMu guards the state file of the API key in use.
+var apiKeyMu sync.Mutex
+
+// getAPIKeys returns api_key followed by the keys of api_keys.
+func getAPIKeys() []string {
+	var keys []string
+	for _, key := range append([]string{config.APIKey}, strings.Split(config.APIKeys, ",")...) {
+		if key = strings.TrimSpace(key); key != "" && !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// getAPIKeyIndex returns the index of the API key in use, kept in the state
+// directory with its month: quotas being monthly, the first key is used
+// again the next month.
+func getAPIKeyIndex() int {
+	stateFile, err := getStateFile("api_key")
+	if err != nil {
+		return 0
+	}
+	data, err := os.ReadFile(stateFile)
+	if err != nil {
+		return 0
+	}
+	month, index, ok := strings.Cut(strings.TrimSpace(string(data)), " ")
+	if !ok || month != time.Now().Format("2006-01") {
+		return 0
+	}
+	n, err := strconv.Atoi(index)
+	if err != nil || n < 0 || n >= len(getAPIKeys()) {
+		return 0
+	}
+	return n
+}
+
+func setAPIKeyIndex(index int) error {
+	stateFile, err := getStateFile("api_key")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(getAppStateDir(), 0755); err != nil {
+		return fmt.Errorf("could not create state directory: %w", err)
+	}
+	data := fmt.Sprintf("%s %d\n", time.Now().Format("2006-01"), index)
+	return os.WriteFile(stateFile, []byte(data), 0644)
+}
+
+// getAPIKey returns the PONS API key in use.
+func getAPIKey() string {
+	keys := getAPIKeys()
+	if len(keys) == 0 {
+		return ""
+	}
+	apiKeyMu.Lock()
+	defer apiKeyMu.Unlock()
+	return keys[getAPIKeyIndex()]
+}
+
+// rotateAPIKey moves on to the next API key, key being the one which hit
+// its quota. It tells whether there is another key to try.
+func rotateAPIKey(key string) bool {
+	keys := getAPIKeys()
+	if len(keys) < 2 {
+		return false
+	}
+
+	apiKeyMu.Lock()
+	defer apiKeyMu.Unlock()
+	index := getAPIKeyIndex()
+	if keys[index] == key {
+		// Another lookup may have rotated the key already
+		index = (index + 1) % len(keys)
+		if err := setAPIKeyIndex(index); err != nil {
+			// Log the error, but don't fail the command
+			slog.Warn("could not save API key in use", "err", err)
+		}
+	}
+	slog.Warn("API key hit its quota, rotating to the next one", "key", redactAPIKey(key), "next", redactAPIKey(keys[index]))
+	printNotice("API key %s hit its quota, going on with %s (%d/%d)\n", redactAPIKey(key), redactAPIKey(keys[index]), index+1, len(keys))
+	return true
+}
+
+// redactAPIKey shows the last characters of an API key only, enough to
+// tell the keys apart.
+func redactAPIKey(key string) string {
+	if len(key) <= 4 {
+		return "…"
+	}
+	return "…" + key[len(key)-4:]
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"archive/zip"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// The directories of a backup archive, and where they are restored.
+var backupDirs = map[string]func() string{
+	"config": getAppConfigDir,
+	"data":   getAppDataDir,
+	"cache":  getAppCacheBaseDir,
+}
+
+// runBackup writes the config files and the database, and the cache if
+// asked, to a zip archive.
+func runBackup(args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	withCache := flags.Bool("cache", false, "also back up the cache")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: pons-cli backup [--cache] <file>")
+	}
+
+	file, err := os.Create(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("could not create backup file: %w", err)
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	if err := addDirToBackup(archive, "config", getAppConfigDir()); err != nil {
+		return err
+	}
+	if err := addDatabaseToBackup(archive); err != nil {
+		return err
+	}
+	if *withCache {
+		if err := addDirToBackup(archive, "cache", getAppCacheBaseDir()); err != nil {
+			return err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("could not write backup file: %w", err)
+	}
+
+	color.New(color.FgGreen).Printf("Backed up to %s\n", flags.Arg(0))
+	return nil
+}
+
+func addDirToBackup(archive *zip.Writer, name, dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		return addFileToBackup(archive, name+"/"+filepath.ToSlash(rel), path)
+	})
+}
+
+func addFileToBackup(archive *zip.Writer, name, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not open %s: %w", path, err)
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return fmt.Errorf("could not stat %s: %w", path, err)
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return fmt.Errorf("could not add %s to backup: %w", name, err)
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	dst, err := archive.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("could not add %s to backup: %w", name, err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		return fmt.Errorf("could not add %s to backup: %w", name, err)
+	}
+	return nil
+}
+
+// addDatabaseToBackup adds a consistent copy of the database, which other
+// instances may be writing to.
+func addDatabaseToBackup(archive *zip.Writer) error {
+	snapshot, err := os.CreateTemp("", "pons-cli-*.db")
+	if err != nil {
+		return fmt.Errorf("could not create database snapshot: %w", err)
+	}
+	snapshot.Close()
+	defer os.Remove(snapshot.Name())
+
+	// VACUUM INTO refuses to overwrite a file
+	os.Remove(snapshot.Name())
+	if _, err := db.Exec("VACUUM INTO ?", snapshot.Name()); err != nil {
+		return fmt.Errorf("could not create database snapshot: %w", err)
+	}
+	return addFileToBackup(archive, "data/pons-cli.db", snapshot.Name())
+}
+
+// runRestore restores the files of a backup archive, replacing the current
+// ones.
+func runRestore(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: pons-cli restore <file>")
+	}
+
+	archive, err := zip.OpenReader(args[0])
+	if err != nil {
+		return fmt.Errorf("could not open backup file: %w", err)
+	}
+	defer archive.Close()
+
+	// The database is replaced, so it must not be in use anymore
+	if err := db.Close(); err != nil {
+		return fmt.Errorf("could not close database: %w", err)
+	}
+
+	return withLock(func() error {
+		for _, entry := range archive.File {
+			if err := restoreFile(entry); err != nil {
+				return err
+			}
+		}
+		color.New(color.FgGreen).Printf("Restored %s\n", args[0])
+		return nil
+	})
+}
+
+func restoreFile(entry *zip.File) error {
+	dirName, rel, ok := strings.Cut(entry.Name, "/")
+	getDir, known := backupDirs[dirName]
+	if !ok || !known || !filepath.IsLocal(rel) {
+		return fmt.Errorf("unexpected file in backup: %s", entry.Name)
+	}
+	path := filepath.Join(getDir(), filepath.FromSlash(rel))
+
+	if entry.Name == "data/pons-cli.db" {
+		// The journal of the replaced database doesn't apply to the restored one
+		for _, suffix := range []string{"-wal", "-shm"} {
+			if err := os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("could not remove %s: %w", path+suffix, err)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not create directory of %s: %w", path, err)
+	}
+	src, err := entry.Open()
+	if err != nil {
+		return fmt.Errorf("could not read %s from backup: %w", entry.Name, err)
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create %s: %w", path, err)
+	}
+	defer dst.Close()
+	if _, err := io.Copy(dst, src); err != nil {
+		return fmt.Errorf("could not restore %s: %w", path, err)
+	}
+	return nil
+}
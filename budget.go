@@ -0,0 +1,57 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var errBudgetExceeded = errors.New("monthly request budget exceeded")
+
+// forceRequests makes API requests even over the monthly budget.
+var forceRequests bool
+
+// getAPIRequests returns the number of PONS API requests made in the month
+// of day.
+func getAPIRequests(day time.Time) (int, error) {
+	var requests int
+	err := db.QueryRow("SELECT requests FROM api_usage WHERE month = ?", day.Format("2006-01")).Scan(&requests)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, fmt.Errorf("could not query API usage: %w", err)
+	}
+	return requests, nil
+}
+
+func countAPIRequest() error {
+	if replayFixturesDir != "" {
+		// Replayed responses cost nothing
+		return nil
+	}
+	_, err := db.Exec(`
+		INSERT INTO api_usage(month, requests) VALUES(?, 1)
+		ON CONFLICT(month) DO UPDATE SET requests = requests + 1
+	`, time.Now().Format("2006-01"))
+	if err != nil || config.MonthlyRequestBudget == 0 {
+		return err
+	}
+	requests, err := getAPIRequests(time.Now())
+	if err != nil {
+		return err
+	}
+	checkQuotaThreshold(requests)
+	return nil
+}
+
+// isBudgetExceeded tells whether the PONS API requests of this month
+// reached monthly_request_budget, unless --force is given.
+func isBudgetExceeded() (bool, error) {
+	if config.MonthlyRequestBudget == 0 || forceRequests || replayFixturesDir != "" {
+		return false, nil
+	}
+	requests, err := getAPIRequests(time.Now())
+	if err != nil {
+		return false, err
+	}
+	return requests >= config.MonthlyRequestBudget, nil
+}
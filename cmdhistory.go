@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/chzyer/readline"
+)
+
+// historyFileName is the file the command history was kept in, before it
+// moved to the database.
+const historyFileName = "cmd_history.txt"
+
+// addCommandHistory adds a command to the command history. A command typed
+// again moves to the end of the history instead of being duplicated.
+func addCommandHistory(command string) error {
+	_, err := db.Exec(`
+		INSERT INTO command_history(command, date) VALUES(?, ?)
+		ON CONFLICT(command) DO UPDATE SET date = excluded.date
+	`, command, time.Now())
+	if err != nil {
+		return fmt.Errorf("could not add command history: %w", err)
+	}
+	return nil
+}
+
+// getCommandHistory returns the last commands of the command history, the
+// oldest first.
+func getCommandHistory(limit int) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT command FROM (
+			SELECT command, date FROM command_history ORDER BY date DESC LIMIT ?
+		) ORDER BY date ASC
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("could not query command history: %w", err)
+	}
+	defer rows.Close()
+
+	var commands []string
+	for rows.Next() {
+		var command string
+		if err := rows.Scan(&command); err != nil {
+			return nil, fmt.Errorf("could not scan row: %w", err)
+		}
+		commands = append(commands, command)
+	}
+	return commands, rows.Err()
+}
+
+// loadCommandHistory fills the history of the line editor with the command
+// history.
+func loadCommandHistory(rl *readline.Instance) error {
+	commands, err := getCommandHistory(config.CmdHistoryLimit)
+	if err != nil {
+		return err
+	}
+	for _, command := range commands {
+		// Without a history file, readline only keeps the command in memory
+		if err := rl.SaveHistory(command); err != nil {
+			return fmt.Errorf("could not load command history: %w", err)
+		}
+	}
+	return nil
+}
+
+// saveCommandHistory adds a command typed at the REPL prompt to the history
+// of the line editor and to the command history.
+func saveCommandHistory(rl *readline.Instance, command string) {
+	if strings.TrimSpace(command) == "" {
+		return
+	}
+	// Log the errors, but don't fail the command
+	if err := rl.SaveHistory(command); err != nil {
+		slog.Warn("could not add command to line editor history", "err", err)
+	}
+	if err := addCommandHistory(command); err != nil {
+		slog.Warn("could not add command history", "err", err)
+	}
+}
+
+func cleanupCommandHistory() error {
+	_, err := db.Exec(`
+		DELETE FROM command_history
+		WHERE id NOT IN (
+			SELECT id FROM command_history
+			ORDER BY date DESC
+			LIMIT ?
+		)
+	`, config.CmdHistoryLimit)
+	if err != nil {
+		return fmt.Errorf("could not clean up command history: %w", err)
+	}
+	return nil
+}
+
+// importHistoryFile moves the commands of the history file of older versions
+// to the command history, keeping their order.
+func importHistoryFile() error {
+	historyFile, err := getStateFile(historyFileName)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(historyFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("could not open history file: %w", err)
+	}
+	defer file.Close()
+
+	var commands []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if command := scanner.Text(); strings.TrimSpace(command) != "" {
+			commands = append(commands, command)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("could not read history file: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin import: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The file has no dates, hence the commands get dates a second apart
+	// before now
+	start := time.Now().Add(-time.Duration(len(commands)) * time.Second)
+	for i, command := range commands {
+		_, err := tx.Exec(`
+			INSERT INTO command_history(command, date) VALUES(?, ?)
+			ON CONFLICT(command) DO UPDATE SET date = excluded.date
+		`, command, start.Add(time.Duration(i)*time.Second))
+		if err != nil {
+			return fmt.Errorf("could not import command history: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not import command history: %w", err)
+	}
+
+	file.Close()
+	if err := os.Remove(historyFile); err != nil {
+		return fmt.Errorf("could not remove history file: %w", err)
+	}
+	return nil
+}
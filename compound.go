@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Shortest part of a compound worth looking up
+const minCompoundPart = 3
+
+// Each try of a split costs a lookup, and API requests are limited. Tries
+// which found no entry are cached, so that they cost nothing the next time.
+const maxCompoundLookups = 16
+
+// Linking elements (Fugenelemente) that may join the parts of a German
+// compound, e.g. the "s" of "Arbeitsplatz"
+var linkingElements = []string{"es", "en", "er", "s", "n", "e"}
+
+type compoundSplitter struct {
+	dict    string
+	lookups int
+	found   map[string]TranslationResponse
+	// Set once the lookups fail otherwise than finding no entry, e.g. over
+	// the monthly budget
+	stopped bool
+}
+
+func isGermanDict(dict string) bool {
+	return len(dict) == 4 && (dict[:2] == "de" || dict[2:] == "de")
+}
+
+// lookupCompound splits a German compound into parts which have an entry,
+// preferring long parts, and returns the parts along with their merged
+// entries.
+func lookupCompound(word, dict string) ([]string, TranslationResponse, error) {
+	s := &compoundSplitter{dict: dict, found: map[string]TranslationResponse{}}
+	parts := s.split(word, true)
+	if len(parts) < 2 {
+		return nil, nil, errNoTranslation
+	}
+
+	var merged TranslationResponse
+	for _, part := range parts {
+		for _, lang := range s.found[part] {
+			if len(merged) == 0 || merged[len(merged)-1].Lang != lang.Lang {
+				merged = append(merged, lang)
+			} else {
+				merged[len(merged)-1].Hits = append(merged[len(merged)-1].Hits, lang.Hits...)
+			}
+		}
+	}
+	return parts, merged, nil
+}
+
+// split returns the parts of word which have an entry, or nil. When whole is
+// false, word itself is tried first.
+func (s *compoundSplitter) split(word string, whole bool) []string {
+	if !whole {
+		if part, ok := s.lookup(word); ok {
+			return []string{part}
+		}
+	}
+
+	runes := []rune(word)
+	for i := minCompoundPart; i <= len(runes)-minCompoundPart; i++ {
+		head, ok := s.lookup(string(runes[i:]))
+		if !ok {
+			continue
+		}
+		for _, left := range getLinkingVariants(string(runes[:i])) {
+			if parts := s.split(left, false); parts != nil {
+				return append(parts, head)
+			}
+		}
+	}
+	return nil
+}
+
+// lookup tells whether word, or its capitalized form since the parts of a
+// compound may be nouns, has an entry.
+func (s *compoundSplitter) lookup(word string) (string, bool) {
+	for _, candidate := range []string{word, capitalize(word)} {
+		if _, ok := s.found[candidate]; ok {
+			return candidate, true
+		}
+	}
+	for _, candidate := range []string{capitalize(word), strings.ToLower(word)} {
+		missFile, err := getCompoundMissFile(candidate, s.dict)
+		if err == nil && isCacheValid(missFile, time.Duration(config.CacheTTL)*time.Second) {
+			continue
+		}
+		if s.stopped || s.lookups >= maxCompoundLookups {
+			return "", false
+		}
+		if overBudget, err := isBudgetExceeded(); err != nil || overBudget {
+			s.stopped = true
+			return "", false
+		}
+		s.lookups++
+		translations, err := getTranslation(candidate, s.dict)
+		if err == nil && len(translations) > 0 {
+			s.found[candidate] = translations
+			return candidate, true
+		}
+		if !errors.Is(err, errNoTranslation) {
+			s.stopped = true
+			return "", false
+		}
+		if missFile != "" {
+			if err := os.WriteFile(missFile, nil, 0644); err != nil {
+				// Log the error, but don't fail the lookup
+				slog.Warn("could not write cache file", "err", err)
+			}
+		}
+	}
+	return "", false
+}
+
+// getCompoundMissFile returns the cache file recording that candidate has no
+// entry in dict, so that splitting compounds again doesn't request it.
+func getCompoundMissFile(candidate, dict string) (string, error) {
+	return getCacheFile("compound_miss_" + getTranslationCacheKey(candidate, dict))
+}
+
+// getLinkingVariants returns the left part of a compound, followed by what
+// remains of it without each linking element it may end with.
+func getLinkingVariants(left string) []string {
+	variants := []string{left}
+	for _, element := range linkingElements {
+		trimmed := strings.TrimSuffix(left, element)
+		if trimmed != left && utf8.RuneCountInString(trimmed) >= minCompoundPart {
+			variants = append(variants, trimmed)
+		}
+	}
+	return variants
+}
+
+func capitalize(word string) string {
+	runes := []rune(strings.ToLower(word))
+	if len(runes) == 0 {
+		return word
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// validateConfig checks the configuration decoded from configFile, so that
+// a mistyped variable or value is reported rather than silently replaced by
+// its default when the file is written back.
+func validateConfig(md toml.MetaData, configFile string) error {
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return fmt.Errorf("unknown variable %s in %s", undecoded[0], configFile)
+	}
+
+	invalid := func(key string, value any) error {
+		return fmt.Errorf("invalid value for %s in %s: %v", key, configFile, value)
+	}
+	if key, value, ok := findNegativeValue(); ok {
+		return invalid(key, value)
+	}
+	if _, ok := tableStyles[config.TableStyle]; md.IsDefined("table_style") && !ok && config.TableStyle != "none" {
+		return invalid("table_style", config.TableStyle)
+	}
+	if md.IsDefined("hyperlinks") && !slices.Contains([]string{"auto", "on", "off"}, config.Hyperlinks) {
+		return invalid("hyperlinks", config.Hyperlinks)
+	}
+	if _, err := parseLogLevel(config.LogLevel); md.IsDefined("log_level") && err != nil {
+		return invalid("log_level", config.LogLevel)
+	}
+	if err := checkProxyURL(config.ProxyURL); err != nil {
+		return invalid("proxy_url", err)
+	}
+	if md.IsDefined("rtl_mode") && !slices.Contains([]string{"off", "swap", "visual"}, config.RTLMode) {
+		return invalid("rtl_mode", config.RTLMode)
+	}
+	if _, ok := sentenceTranslators[config.MTProvider]; md.IsDefined("mt_provider") && !ok {
+		return invalid("mt_provider", config.MTProvider)
+	}
+	if _, ok := providers[config.Provider]; md.IsDefined("provider") && !ok {
+		return invalid("provider", config.Provider)
+	}
+	if _, ok := schedulers[config.SRSAlgorithm]; md.IsDefined("srs_algorithm") && !ok {
+		return invalid("srs_algorithm", config.SRSAlgorithm)
+	}
+	if _, err := parseGenderPalette(config.GenderPalette); md.IsDefined("gender_palette") && err != nil {
+		return invalid("gender_palette", err)
+	}
+	if md.IsDefined("editing_mode") && !slices.Contains([]string{"emacs", "vi"}, config.EditingMode) {
+		return invalid("editing_mode", config.EditingMode)
+	}
+	if _, err := time.LoadLocation(config.Timezone); md.IsDefined("timezone") && err != nil {
+		return invalid("timezone", config.Timezone)
+	}
+	if md.IsDefined("date_format") && config.DateFormat == "" {
+		return invalid("date_format", config.DateFormat)
+	}
+	if md.IsDefined("query_case") && !slices.Contains(queryCases, config.QueryCase) {
+		return invalid("query_case", config.QueryCase)
+	}
+	if md.IsDefined("sort") && !slices.Contains(sortOrders, config.Sort) {
+		return invalid("sort", config.Sort)
+	}
+	if md.IsDefined("frequency_annotation") && !slices.Contains(frequencyAnnotations, config.FrequencyAnnotation) {
+		return invalid("frequency_annotation", config.FrequencyAnnotation)
+	}
+	if err := validateKeybindings(config.Keybindings); err != nil {
+		return invalid("keybindings", err)
+	}
+	if err := validateThesauruses(config.Thesaurus); err != nil {
+		return invalid("thesaurus", err)
+	}
+	if err := validateDeckRules(config.DeckRules); err != nil {
+		return invalid("deck_rules", err)
+	}
+	return nil
+}
+
+// findNegativeValue returns the first of the counts, limits and durations
+// of the configuration set to a negative value, which .set rejects too.
+func findNegativeValue() (string, int, bool) {
+	values := []struct {
+		key   string
+		value int
+	}{
+		{"cache_ttl", config.CacheTTL},
+		{"cmd_history_limit", config.CmdHistoryLimit},
+		{"search_history_limit", config.SearchHistoryLimit},
+		{"monthly_request_budget", config.MonthlyRequestBudget},
+		{"cell_padding", config.CellPadding},
+		{"connect_timeout", config.ConnectTimeout},
+		{"http_timeout", config.HTTPTimeout},
+		{"new_cards_per_day", config.NewCardsPerDay},
+		{"reviews_per_day", config.ReviewsPerDay},
+	}
+	for _, v := range values {
+		if v.value < 0 {
+			return v.key, v.value, true
+		}
+	}
+	return "", 0, false
+}
+
+// reloadConfig reloads the configuration after the config file changed,
+// filling the variables it lacks with their default.
+func reloadConfig() error {
+	previousConfig := config
+	config = Config{}
+	if err := setupConfig(); err != nil {
+		config = previousConfig
+		return err
+	}
+	applyAccessibleMode()
+	applyEditingMode()
+	return nil
+}
+
+// handleUnsetCommand reverts a variable to its default, by removing it from
+// the config file so that the default is written back.
+func handleUnsetCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: .unset <var>")
+	}
+	key := args[0]
+	if !slices.Contains(getConfigKeys(), key) {
+		return fmt.Errorf("unknown variable: %s", key)
+	}
+
+	configFile := getConfigFile()
+	values := map[string]any{}
+	if _, err := toml.DecodeFile(configFile, &values); err != nil {
+		return fmt.Errorf("could not decode config file: %w", err)
+	}
+	delete(values, key)
+
+	file, err := os.Create(configFile)
+	if err != nil {
+		return fmt.Errorf("could not create config file: %w", err)
+	}
+	defer file.Close()
+	if err := toml.NewEncoder(file).Encode(values); err != nil {
+		return fmt.Errorf("could not encode config to file: %w", err)
+	}
+
+	return reloadConfig()
+}
+
+// credentialKeys are the variables .set --reset keeps, so that resetting
+// the settings doesn't log out of PONS or the translation provider.
+var credentialKeys = []string{"api_key", "api_keys", "mt_api_key"}
+
+// resetConfig restores the default value of every variable but the API
+// keys.
+func resetConfig() error {
+	configFile := getConfigFile()
+	values := map[string]any{}
+	if _, err := toml.DecodeFile(configFile, &values); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not decode config file: %w", err)
+	}
+	for key := range values {
+		if !slices.Contains(credentialKeys, key) {
+			delete(values, key)
+		}
+	}
+
+	file, err := os.Create(configFile)
+	if err != nil {
+		return fmt.Errorf("could not create config file: %w", err)
+	}
+	defer file.Close()
+	if err := toml.NewEncoder(file).Encode(values); err != nil {
+		return fmt.Errorf("could not encode config to file: %w", err)
+	}
+
+	return reloadConfig()
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"golang.org/x/net/html"
+)
+
+const wiktionaryPageURL = "https://en.wiktionary.org/api/rest_v1/page/html/"
+
+// inflectionTable is a conjugation or declension table of a Wiktionary page.
+type inflectionTable struct {
+	Title string
+	Rows  [][]inflectionCell
+}
+
+type inflectionCell struct {
+	Text   string
+	Header bool
+}
+
+// getInflectionTables returns the inflection tables of the section of a
+// Wiktionary page about the language named language.
+func getInflectionTables(page []byte, language string) ([]inflectionTable, error) {
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse page: %w", err)
+	}
+
+	var tables []inflectionTable
+	for _, n := range getLanguageSection(doc, language) {
+		for _, t := range findInflectionTables(n) {
+			if it := parseInflectionTable(t); len(it.Rows) > 0 {
+				tables = append(tables, it)
+			}
+		}
+	}
+	return tables, nil
+}
+
+// getLanguageSection returns the nodes of the section whose heading is
+// language. The REST API wraps sections in <section> elements, pages
+// rendered otherwise have their headings followed by the section.
+func getLanguageSection(doc *html.Node, language string) []*html.Node {
+	var heading *html.Node
+	var find func(*html.Node)
+	find = func(n *html.Node) {
+		if heading != nil {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "h2" && strings.TrimSpace(nodeText(n)) == language {
+			heading = n
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			find(c)
+		}
+	}
+	find(doc)
+	if heading == nil {
+		return nil
+	}
+
+	if heading.Parent != nil && heading.Parent.Data == "section" {
+		return []*html.Node{heading.Parent}
+	}
+	var nodes []*html.Node
+	for n := heading.NextSibling; n != nil; n = n.NextSibling {
+		if n.Type == html.ElementNode && n.Data == "h2" {
+			break
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+func findInflectionTables(n *html.Node) []*html.Node {
+	if n.Type == html.ElementNode && n.Data == "table" && hasClass(n, "inflection-table") {
+		return []*html.Node{n}
+	}
+	var tables []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		tables = append(tables, findInflectionTables(c)...)
+	}
+	return tables
+}
+
+// parseInflectionTable returns the rows of a table, cells spanning several
+// columns being repeated in each of them.
+func parseInflectionTable(t *html.Node) inflectionTable {
+	var it inflectionTable
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		switch n.Data {
+		case "caption":
+			it.Title = cellText(n)
+			return
+		case "table":
+			// Tables nested in cells are read as their text
+			if n != t {
+				return
+			}
+		case "tr":
+			var row []inflectionCell
+			empty := true
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type != html.ElementNode || (c.Data != "th" && c.Data != "td") {
+					continue
+				}
+				cell := inflectionCell{Text: cellText(c), Header: c.Data == "th"}
+				if cell.Text != "" {
+					empty = false
+				}
+				span := 1
+				for _, attr := range c.Attr {
+					if attr.Key == "colspan" {
+						if v, err := strconv.Atoi(attr.Val); err == nil && v > 1 {
+							span = v
+						}
+					}
+				}
+				for range span {
+					row = append(row, cell)
+				}
+			}
+			if !empty {
+				it.Rows = append(it.Rows, row)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(t)
+	return it
+}
+
+// cellText returns the text of a cell, without its footnote references and
+// with its whitespace collapsed.
+func cellText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			sb.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			switch n.Data {
+			case "sup", "style", "script":
+				return
+			case "br":
+				sb.WriteString(" ")
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// renderInflectionTable renders an inflection table as a grid, its header
+// cells in bold and the cells spanning several columns merged.
+func renderInflectionTable(it inflectionTable) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	// Grids need their borders and separators, whatever table_style is
+	style := getTableStyle()
+	style.Options.DrawBorder, style.Options.SeparateColumns, style.Options.SeparateRows = true, true, true
+	t.SetStyle(style)
+	if it.Title != "" {
+		t.SetTitle(it.Title)
+	}
+	bold := color.New(color.Bold)
+	for _, cells := range it.Rows {
+		row := make(table.Row, len(cells))
+		for i, cell := range cells {
+			if cell.Header {
+				row[i] = bold.Sprint(cell.Text)
+			} else {
+				row[i] = cell.Text
+			}
+		}
+		t.AppendRow(row, table.RowConfig{AutoMerge: true})
+	}
+	t.Render()
+}
+
+// handleConjugateCommand shows the conjugation or declension tables of the
+// headword of an entry of the last result, numbered like .show #<n>, taken
+// from the English Wiktionary as the PONS API doesn't have them.
+func handleConjugateCommand(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: .conjugate [<n>]")
+	}
+	result := getLastResult()
+	if result == nil {
+		return fmt.Errorf("no previous lookup")
+	}
+	n := 1
+	if len(args) == 1 {
+		var err error
+		if n, err = strconv.Atoi(strings.TrimPrefix(args[0], "#")); err != nil {
+			return fmt.Errorf("usage: .conjugate [<n>]")
+		}
+	}
+	entries := getNumberedEntries(result.Translations)
+	if n < 1 || n > countEntries(entries) {
+		return fmt.Errorf("no such entry: %d", n)
+	}
+	entry, rom, _ := getEntry(entries, n)
+
+	// Wiktionary titles keep the case of the headword
+	headword := getPlainHeadword(rom)
+	language := getLanguageName(entry[0].Lang)
+
+	page, err := fetchWiktionaryPage(wiktionaryPageURL, "conjugate_", ".html", headword)
+	if err != nil {
+		if errors.Is(err, errNoTranslation) {
+			return fmt.Errorf("no inflection table for %s", headword)
+		}
+		return err
+	}
+	tables, err := getInflectionTables(page, language)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		return fmt.Errorf("no inflection table for %s in %s", headword, language)
+	}
+
+	for _, t := range tables {
+		renderInflectionTable(t)
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+// enableVirtualTerminal is a no-op outside of Windows, where terminals
+// interpret ANSI escape sequences.
+func enableVirtualTerminal() bool {
+	return true
+}
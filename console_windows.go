@@ -0,0 +1,28 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// enableVirtualTerminal makes the console interpret ANSI escape sequences,
+// which Windows 10 and later support. It returns false on older consoles,
+// where only the colors of the color-aware writers work.
+func enableVirtualTerminal() bool {
+	enabled := true
+	for _, file := range []*os.File{os.Stdout, os.Stderr} {
+		handle := windows.Handle(file.Fd())
+		var mode uint32
+		if err := windows.GetConsoleMode(handle, &mode); err != nil {
+			// Not a console, e.g. redirected to a file
+			continue
+		}
+		if err := windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil {
+			enabled = false
+		}
+	}
+	return enabled
+}
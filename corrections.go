@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// saveCorrection stores the translation the user prefers for a headword of
+// dict, replacing the previous one.
+func saveCorrection(dict, headword, correction string) error {
+	_, err := db.Exec(`
+		INSERT INTO corrections(dict, headword, correction, date) VALUES(?, ?, ?, ?)
+		ON CONFLICT(dict, headword) DO UPDATE SET correction = excluded.correction, date = excluded.date
+	`, dict, normalizeHeadword(headword), correction, time.Now())
+	if err != nil {
+		return fmt.Errorf("could not save correction: %w", err)
+	}
+	return nil
+}
+
+func removeCorrection(dict, headword string) (int64, error) {
+	result, err := db.Exec("DELETE FROM corrections WHERE dict = ? AND headword = ?", dict, normalizeHeadword(headword))
+	if err != nil {
+		return 0, fmt.Errorf("could not remove correction: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// getCorrections returns the corrections of the headwords of dict, by
+// normalized headword.
+func getCorrections(dict string) (map[string]string, error) {
+	rows, err := db.Query("SELECT headword, correction FROM corrections WHERE dict = ?", dict)
+	if err != nil {
+		return nil, fmt.Errorf("could not query corrections: %w", err)
+	}
+	defer rows.Close()
+
+	corrections := map[string]string{}
+	for rows.Next() {
+		var headword, correction string
+		if err := rows.Scan(&headword, &correction); err != nil {
+			return nil, fmt.Errorf("could not scan row: %w", err)
+		}
+		corrections[headword] = correction
+	}
+	return corrections, rows.Err()
+}
+
+// loadCorrections returns the corrections shown with the entries of dict,
+// or none if they can't be read.
+func loadCorrections(dict string) map[string]string {
+	if db == nil {
+		return nil
+	}
+	corrections, err := getCorrections(dict)
+	if err != nil {
+		// Log the error, but don't fail the rendering
+		slog.Warn("could not load corrections", "err", err)
+	}
+	return corrections
+}
+
+// formatCorrection returns the line shown under an entry the user
+// corrected.
+func formatCorrection(correction string) string {
+	return color.New(color.FgMagenta).Sprintf("✎ %s", correction)
+}
+
+// handleCorrectCommand corrects the translation of an entry of the last
+// result, numbered like .show #<n>, removes the correction when no
+// translation is given, or lists the corrections without arguments.
+func handleCorrectCommand(args []string) error {
+	if len(args) == 0 {
+		return listCorrections()
+	}
+
+	result := getLastResult()
+	if result == nil {
+		return fmt.Errorf("no previous lookup")
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil {
+		return fmt.Errorf("usage: .correct [<n> [<translation>]]")
+	}
+	entries := getNumberedEntries(result.Translations)
+	if n < 1 || n > countEntries(entries) {
+		return fmt.Errorf("no such entry: %s", args[0])
+	}
+	_, rom, _ := getEntry(entries, n)
+
+	if len(args) == 1 {
+		removed, err := removeCorrection(result.Dict, rom.Headword)
+		if err != nil {
+			return err
+		}
+		if removed == 0 {
+			return fmt.Errorf("no correction for %s", rom.Headword)
+		}
+		printNotice("Correction of %s removed\n", rom.Headword)
+		return nil
+	}
+
+	if err := saveCorrection(result.Dict, rom.Headword, strings.Join(args[1:], " ")); err != nil {
+		return err
+	}
+	printNotice("Correction of %s saved\n", rom.Headword)
+	return nil
+}
+
+func listCorrections() error {
+	rows, err := db.Query("SELECT dict, headword, correction, date FROM corrections ORDER BY date DESC")
+	if err != nil {
+		return fmt.Errorf("could not query corrections: %w", err)
+	}
+	defer rows.Close()
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Headword", "Dictionary", "Correction", "Date"})
+	count := 0
+	for rows.Next() {
+		var dict, headword, correction string
+		var date time.Time
+		if err := rows.Scan(&dict, &headword, &correction, &date); err != nil {
+			return fmt.Errorf("could not scan row: %w", err)
+		}
+		t.AppendRow(table.Row{headword, dict, correction, date.In(getLocation()).Format("2006-01-02 15:04:05")})
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if count == 0 {
+		printNotice("No corrections\n")
+		return nil
+	}
+	t.Render()
+	return nil
+}
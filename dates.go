@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// getLocation returns the time zone dates are shown in.
+func getLocation() *time.Location {
+	location, err := time.LoadLocation(config.Timezone)
+	if err != nil {
+		// The time zone is validated with the configuration
+		return time.Local
+	}
+	return location
+}
+
+// formatDay returns the heading of the searches made on the day of t, named
+// after today and yesterday.
+func formatDay(t, now time.Time) string {
+	day := t.Format(config.DateFormat)
+	switch {
+	case sameDay(t, now):
+		return "Today, " + day
+	case sameDay(t, now.AddDate(0, 0, -1)):
+		return "Yesterday, " + day
+	}
+	return day
+}
+
+// startOfDay returns the midnight starting the day of t, in the configured
+// time zone.
+func startOfDay(t time.Time) time.Time {
+	t = t.In(getLocation())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+// formatRelativeTime returns how long before now t was, like "2h ago".
+func formatRelativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo ago", int(d.Hours()/24/30))
+	}
+	return fmt.Sprintf("%dy ago", int(d.Hours()/24/365))
+}
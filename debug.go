@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// debugFlag turns the debug mode on for this run, like .set debug on.
+var debugFlag bool
+
+var debugLogMu sync.Mutex
+
+// secretHeaders are the request headers carrying API keys.
+var secretHeaders = []string{"X-Secret", "Authorization"}
+
+func isDebugEnabled() bool {
+	return debugFlag || config.Debug
+}
+
+// redactSecrets removes the API keys from an HTTP dump, be they in headers,
+// query strings or bodies.
+func redactSecrets(dump string) string {
+	for _, secret := range append(getAPIKeys(), config.MTAPIKey) {
+		if secret != "" {
+			dump = strings.ReplaceAll(dump, secret, "[REDACTED]")
+		}
+	}
+	return dump
+}
+
+// writeDebugLog appends an HTTP dump to debug.log in the state directory.
+func writeDebugLog(title string, dump []byte) {
+	debugLogMu.Lock()
+	defer debugLogMu.Unlock()
+
+	path, err := getStateFile("debug.log")
+	if err != nil {
+		slog.Warn("could not get debug log path", "err", err)
+		return
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		slog.Warn("could not open debug log", "err", err)
+		return
+	}
+	defer file.Close()
+
+	entry := fmt.Sprintf("=== %s %s\n%s\n\n", time.Now().Format(time.RFC3339Nano), title, dump)
+	_, err = file.WriteString(redactSecrets(entry))
+	if err != nil {
+		slog.Warn("could not write debug log", "err", err)
+	}
+}
+
+// debugRequest logs a request, its body included.
+func debugRequest(req *http.Request) {
+	redacted := req.Clone(req.Context())
+	for _, header := range secretHeaders {
+		if redacted.Header.Get(header) != "" {
+			redacted.Header.Set(header, "[REDACTED]")
+		}
+	}
+	if req.GetBody != nil {
+		// The body of the clone is the one of req, which must stay unread
+		body, err := req.GetBody()
+		if err == nil {
+			redacted.Body = body
+		}
+	}
+	dump, err := httputil.DumpRequestOut(redacted, req.GetBody != nil)
+	if err != nil {
+		slog.Warn("could not dump request", "err", err)
+		return
+	}
+	writeDebugLog("request", dump)
+}
+
+// debugResponse logs a response, its body included. The body stays
+// readable.
+func debugResponse(resp *http.Response, duration time.Duration) {
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		slog.Warn("could not dump response", "err", err)
+		return
+	}
+	writeDebugLog(fmt.Sprintf("response to %s %s in %s", resp.Request.Method, resp.Request.URL.Redacted(), duration), dump)
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// defaultDeckName names the default deck in commands, the cards of which
+// have no deck.
+const defaultDeckName = "default"
+
+// getDeckName returns the name of a deck as shown, the default deck being
+// stored as ”.
+func getDeckName(deck string) string {
+	if deck == "" {
+		return defaultDeckName
+	}
+	return deck
+}
+
+// getDeck returns the deck stored for a name given in a command.
+func getDeck(name string) string {
+	if name == defaultDeckName {
+		return ""
+	}
+	return name
+}
+
+// getDefaultDeck returns the deck the words saved in dict go to, set in the
+// [default_decks] section of the configuration, where the default deck may
+// be named too.
+func getDefaultDeck(dict string) string {
+	return getDeck(config.DefaultDecks[dict])
+}
+
+// formatDefaultDecks lists the default decks of the dictionaries, for
+// .set.
+func formatDefaultDecks() string {
+	dicts := slices.Sorted(maps.Keys(config.DefaultDecks))
+	decks := make([]string, len(dicts))
+	for i, dict := range dicts {
+		decks[i] = fmt.Sprintf("%s=%s", dict, config.DefaultDecks[dict])
+	}
+	if len(decks) == 0 {
+		return "(none)"
+	}
+	return strings.Join(decks, ", ")
+}
+
+func deckExists(deck string) (bool, error) {
+	if deck == "" {
+		return true, nil
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM decks WHERE name = ?", deck).Scan(&count); err != nil {
+		return false, fmt.Errorf("could not query decks: %w", err)
+	}
+	return count > 0, nil
+}
+
+// requireDeck fails unless a deck exists.
+func requireDeck(deck string) error {
+	exists, err := deckExists(deck)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("no deck named %s", deck)
+	}
+	return nil
+}
+
+// ensureDeck creates a deck unless it exists, for the decks cards are saved
+// to.
+func ensureDeck(deck string) error {
+	if deck == "" {
+		return nil
+	}
+	if _, err := db.Exec("INSERT INTO decks(name, created) VALUES(?, ?) ON CONFLICT(name) DO NOTHING", deck, time.Now()); err != nil {
+		return fmt.Errorf("could not create deck: %w", err)
+	}
+	return nil
+}
+
+func createDeck(name string) error {
+	if name == defaultDeckName {
+		return fmt.Errorf("%s is the name of the default deck", defaultDeckName)
+	}
+	exists, err := deckExists(name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("deck %s already exists", name)
+	}
+	return ensureDeck(name)
+}
+
+func listDecks() error {
+	rows, err := db.Query(`
+		SELECT name, COUNT(f.id), COALESCE(SUM(f.due <= ?), 0)
+		FROM (SELECT '' AS name UNION SELECT name FROM decks) d
+		LEFT JOIN flashcards f ON f.deck = d.name
+		GROUP BY name
+		ORDER BY name
+	`, time.Now())
+	if err != nil {
+		return fmt.Errorf("could not query decks: %w", err)
+	}
+	defer rows.Close()
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Deck", "Cards", "Due"})
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 2, Align: text.AlignRight},
+		{Number: 3, Align: text.AlignRight},
+	})
+	for rows.Next() {
+		var name string
+		var cards, due int
+		if err := rows.Scan(&name, &cards, &due); err != nil {
+			return fmt.Errorf("could not scan row: %w", err)
+		}
+		t.AppendRow(table.Row{getDeckName(name), cards, due})
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	t.Render()
+	return nil
+}
+
+// renameDeck renames a deck, along with its cards and the settings naming
+// it.
+func renameDeck(oldName, newName string) error {
+	if oldName == defaultDeckName || newName == defaultDeckName {
+		return fmt.Errorf("the default deck can't be renamed")
+	}
+	if err := requireDeck(oldName); err != nil {
+		return err
+	}
+	exists, err := deckExists(newName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("deck %s already exists", newName)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not rename deck: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("UPDATE decks SET name = ? WHERE name = ?", newName, oldName); err != nil {
+		return fmt.Errorf("could not rename deck: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE flashcards SET deck = ? WHERE deck = ?", newName, oldName); err != nil {
+		return fmt.Errorf("could not rename deck: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not rename deck: %w", err)
+	}
+	return renameConfigDeck(oldName, newName)
+}
+
+// renameConfigDeck renames a deck in the [default_decks] and [[deck_rules]]
+// sections of the configuration, which would otherwise create it again.
+func renameConfigDeck(oldName, newName string) error {
+	changed := false
+	for dict, deck := range config.DefaultDecks {
+		if deck == oldName {
+			config.DefaultDecks[dict] = newName
+			changed = true
+		}
+	}
+	for i := range config.DeckRules {
+		if config.DeckRules[i].Deck == oldName {
+			config.DeckRules[i].Deck = newName
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return writeConfig()
+}
+
+// deleteDeck deletes a deck, its cards going to the default deck rather
+// than being lost with their scheduling.
+func deleteDeck(name string) (int64, error) {
+	if name == defaultDeckName {
+		return 0, fmt.Errorf("the default deck can't be deleted")
+	}
+	if err := requireDeck(name); err != nil {
+		return 0, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("could not delete deck: %w", err)
+	}
+	defer tx.Rollback()
+	result, err := tx.Exec("UPDATE flashcards SET deck = '' WHERE deck = ?", name)
+	if err != nil {
+		return 0, fmt.Errorf("could not delete deck: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM decks WHERE name = ?", name); err != nil {
+		return 0, fmt.Errorf("could not delete deck: %w", err)
+	}
+	moved, _ := result.RowsAffected()
+	return moved, tx.Commit()
+}
+
+// moveWord moves the cards of a word saved in dict to a deck.
+func moveWord(word, dict, deck string) error {
+	if err := requireDeck(deck); err != nil {
+		return err
+	}
+	card, found, err := findWordCard(word, dict)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%s is not saved in %s", word, dict)
+	}
+	// The cloze cards of the word go along
+	if _, err := db.Exec("UPDATE flashcards SET deck = ? WHERE dict = ? AND word = ?", deck, dict, card.Word); err != nil {
+		return fmt.Errorf("could not move flashcards: %w", err)
+	}
+	return nil
+}
+
+// handleDeckCommand lists, creates, renames and deletes the decks of the
+// flashcards, and moves saved words between them.
+func handleDeckCommand(args []string) error {
+	if len(args) == 0 || (len(args) == 1 && args[0] == "list") {
+		return listDecks()
+	}
+
+	switch {
+	case args[0] == "create" && len(args) == 2:
+		if err := createDeck(args[1]); err != nil {
+			return err
+		}
+		color.New(color.FgGreen).Printf("Deck %s created\n", args[1])
+	case args[0] == "rename" && len(args) == 3:
+		if err := renameDeck(args[1], args[2]); err != nil {
+			return err
+		}
+		color.New(color.FgGreen).Printf("Deck %s renamed to %s\n", args[1], args[2])
+	case args[0] == "delete" && len(args) == 2:
+		moved, err := deleteDeck(args[1])
+		if err != nil {
+			return err
+		}
+		color.New(color.FgGreen).Printf("Deck %s deleted", args[1])
+		if moved > 0 {
+			fmt.Printf(", %d cards moved to the %s deck", moved, defaultDeckName)
+		}
+		fmt.Println()
+	case args[0] == "move" && len(args) >= 3:
+		if currentDict == "" {
+			return fmt.Errorf("no dictionary selected. Use .dict <key> to select one")
+		}
+		// The word may be a phrase, the deck being the last argument
+		word, deck := strings.Join(args[1:len(args)-1], " "), args[len(args)-1]
+		if err := moveWord(word, currentDict, getDeck(deck)); err != nil {
+			return err
+		}
+		color.New(color.FgGreen).Printf("%s moved to the %s deck\n", word, deck)
+	default:
+		return fmt.Errorf("usage: .deck [list], .deck create|delete <name>, .deck rename <name> <new name> or .deck move <word> <deck>")
+	}
+	return nil
+}
+
+// deckRule assigns the words saved to a deck, when they match all of its
+// conditions: their dictionary, one of their tags and their word class.
+type deckRule struct {
+	Dict      string `toml:"dict"`
+	Tag       string `toml:"tag"`
+	WordClass string `toml:"word_class"`
+	Deck      string `toml:"deck"`
+}
+
+func (r deckRule) String() string {
+	var conditions []string
+	for _, condition := range [][2]string{{"dict", r.Dict}, {"tag", r.Tag}, {"word_class", r.WordClass}} {
+		if condition[1] != "" {
+			conditions = append(conditions, condition[0]+"="+condition[1])
+		}
+	}
+	return strings.Join(conditions, " ") + " -> " + r.Deck
+}
+
+// validateDeckRules checks the rules of the [[deck_rules]] tables.
+func validateDeckRules(rules []deckRule) error {
+	for i, rule := range rules {
+		if rule.Deck == "" {
+			return fmt.Errorf("rule %d has no deck", i+1)
+		}
+		if rule.Dict == "" && rule.Tag == "" && rule.WordClass == "" {
+			return fmt.Errorf("rule %d has no dict, tag or word_class", i+1)
+		}
+	}
+	return nil
+}
+
+// formatDeckRules lists the deck rules, for .set.
+func formatDeckRules() string {
+	if len(config.DeckRules) == 0 {
+		return "(none)"
+	}
+	rules := make([]string, len(config.DeckRules))
+	for i, rule := range config.DeckRules {
+		rules[i] = rule.String()
+	}
+	return strings.Join(rules, ", ")
+}
+
+// getWordClasses returns the word classes of the entries of a lookup
+// result, like "noun" or "transitive verb".
+func getWordClasses(translations TranslationResponse) []string {
+	var classes []string
+	for _, lang := range translations {
+		for _, hit := range lang.Hits {
+			for _, rom := range hit.Roms {
+				if rom.WordClass != "" && !slices.Contains(classes, rom.WordClass) {
+					classes = append(classes, rom.WordClass)
+				}
+			}
+		}
+	}
+	return classes
+}
+
+// matchDeckRule returns the deck of the first rule a card matches, which
+// may be the default deck. Word classes match when they contain the one of
+// the rule, so that "verb" matches "transitive verb" too.
+func matchDeckRule(card flashcard, translations TranslationResponse) (string, bool) {
+	tags := strings.Fields(card.Tags)
+	classes := getWordClasses(translations)
+	for _, rule := range config.DeckRules {
+		if rule.Dict != "" && rule.Dict != card.Dict {
+			continue
+		}
+		if rule.Tag != "" && !slices.Contains(tags, rule.Tag) {
+			continue
+		}
+		if rule.WordClass != "" && !slices.ContainsFunc(classes, func(class string) bool {
+			return strings.Contains(strings.ToLower(class), strings.ToLower(rule.WordClass))
+		}) {
+			continue
+		}
+		return getDeck(rule.Deck), true
+	}
+	return "", false
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"net"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// dictProvider looks words up on a DICT protocol (RFC 2229) server, such as
+// dict.org, whose FreeDict databases ("fd-deu-eng"...) are bilingual.
+type dictProvider struct{}
+
+const dictTimeout = 10 * time.Second
+
+func init() {
+	registerProvider("dict", dictProvider{})
+}
+
+func dialDictServer() (*textproto.Conn, error) {
+	server := config.DictServer
+	if !strings.Contains(server, ":") {
+		server += ":2628"
+	}
+
+	conn, err := net.DialTimeout("tcp", server, dictTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to dict server: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(dictTimeout))
+
+	c := textproto.NewConn(conn)
+	if _, _, err := c.ReadCodeLine(220); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("bad dict server banner: %w", err)
+	}
+	if _, err := dictCommand(c, 250, "CLIENT pons-cli/%s", version); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func dictCommand(c *textproto.Conn, expectCode int, format string, args ...any) (string, error) {
+	if err := c.PrintfLine(format, args...); err != nil {
+		return "", fmt.Errorf("could not send dict command: %w", err)
+	}
+	_, message, err := c.ReadCodeLine(expectCode)
+	return message, err
+}
+
+func closeDictConn(c *textproto.Conn) {
+	c.PrintfLine("QUIT")
+	c.Close()
+}
+
+// getDictDatabases returns the databases of the server, by dictionary key.
+func getDictDatabases() (map[string]Dictionary, map[string]string, error) {
+	c, err := dialDictServer()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeDictConn(c)
+
+	if _, err := dictCommand(c, 110, "SHOW DB"); err != nil {
+		return nil, nil, fmt.Errorf("could not list dict databases: %w", err)
+	}
+	lines, err := c.ReadDotLines()
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read dict databases: %w", err)
+	}
+	if _, _, err := c.ReadCodeLine(250); err != nil {
+		return nil, nil, err
+	}
+
+	dictionaries := map[string]Dictionary{}
+	databases := map[string]string{}
+	for _, line := range lines {
+		name, description, _ := strings.Cut(line, " ")
+		key, langs := getLocalDictKey(strings.TrimPrefix(name, "fd-"))
+		dictionaries[key] = Dictionary{Key: key, SimpleLabel: strings.Trim(description, `"`), Languages: langs}
+		databases[key] = name
+	}
+	return dictionaries, databases, nil
+}
+
+func (dictProvider) Dictionaries() ([]Dictionary, error) {
+	dictionaries, _, err := getDictDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Dictionary
+	for _, dict := range dictionaries {
+		list = append(list, dict)
+	}
+	sortDictionaries(list)
+	return list, nil
+}
+
+func (dictProvider) Lookup(word, dict string) (TranslationResponse, bool, error) {
+	cacheFile, err := getCacheFile("dict_" + getTranslationCacheKey(word, config.DictServer+"_"+dict) + ".json")
+	if err != nil {
+		return nil, false, err
+	}
+
+	cacheTTL := time.Duration(config.CacheTTL) * time.Second
+	if isCacheValid(cacheFile, cacheTTL) {
+		body, err := readCacheFile(cacheFile)
+		if err != nil {
+			return nil, false, err
+		}
+		var translations TranslationResponse
+		if err := json.Unmarshal(body, &translations); err != nil {
+			return nil, false, fmt.Errorf("could not unmarshal cached json: %w", err)
+		}
+		return translations, true, nil
+	}
+
+	_, databases, err := getDictDatabases()
+	if err != nil {
+		return nil, false, err
+	}
+	database, ok := databases[dict]
+	if !ok {
+		return nil, false, fmt.Errorf("unknown dictionary key: %s", dict)
+	}
+
+	translations, err := defineDictWord(word, dict, database)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Write to cache
+	if body, err := json.Marshal(translations); err == nil {
+		if err := writeCacheFile(cacheFile, body); err != nil {
+			// Log this error, but don't fail the command
+			slog.Warn("could not write cache file", "err", err)
+		}
+	}
+
+	return translations, false, nil
+}
+
+func defineDictWord(word, dict, database string) (TranslationResponse, error) {
+	c, err := dialDictServer()
+	if err != nil {
+		return nil, err
+	}
+	defer closeDictConn(c)
+
+	quoted := strings.ReplaceAll(word, `"`, `\"`)
+	if err := c.PrintfLine(`DEFINE %s "%s"`, database, quoted); err != nil {
+		return nil, fmt.Errorf("could not send dict command: %w", err)
+	}
+	code, _, err := c.ReadCodeLine(150)
+	if code == 552 {
+		return nil, errNoTranslation
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch translation: %w", err)
+	}
+
+	var arabs []Arab
+	for {
+		code, message, err := c.ReadCodeLine(0)
+		if err != nil && code == 0 {
+			return nil, fmt.Errorf("could not read definition: %w", err)
+		}
+		if code != 151 {
+			break
+		}
+		// 151 "word" database "description"
+		description := message
+		if parts := strings.SplitN(message, `"`, 5); len(parts) == 5 {
+			description = parts[3]
+		}
+		lines, err := c.ReadDotLines()
+		if err != nil {
+			return nil, fmt.Errorf("could not read definition: %w", err)
+		}
+		arabs = append(arabs, Arab{
+			Header:       html.EscapeString(description),
+			Translations: []Translation{{Source: html.EscapeString(word), Target: html.EscapeString(strings.TrimSpace(strings.Join(lines, "\n")))}},
+		})
+	}
+
+	lang := ""
+	if len(dict) == 4 {
+		lang = dict[:2]
+	}
+	return TranslationResponse{{Lang: lang, Hits: []Hit{{Roms: []Rom{{Headword: word, Arabs: arabs}}}}}}, nil
+}
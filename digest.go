@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// digestTranslations is the number of translations of each word a digest
+// lists.
+const digestTranslations = 3
+
+// digestPeriods are the periods a digest can cover.
+var digestPeriods = []string{"today", "week"}
+
+type digestWord struct {
+	Term string
+	Dict string
+}
+
+// getDigestStart returns when the period of a digest began: today's
+// midnight, or Monday's for the week.
+func getDigestStart(period string, now time.Time) time.Time {
+	start := startOfDay(now)
+	if period == "week" {
+		// Weeks start on Monday
+		start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+	}
+	return start
+}
+
+// getDigestWords returns the words found since start, in the order they
+// were first looked up. Dates are saved in the local time zone, like start
+// is compared in.
+func getDigestWords(start time.Time) ([]digestWord, error) {
+	rows, err := db.Query(`
+		SELECT searched_term, dict FROM search_history
+		WHERE found = 1 AND date >= ?
+		GROUP BY searched_term, dict
+		ORDER BY MIN(date)
+	`, start.Local())
+	if err != nil {
+		return nil, fmt.Errorf("could not query search history: %w", err)
+	}
+	defer rows.Close()
+
+	var words []digestWord
+	for rows.Next() {
+		var word digestWord
+		if err := rows.Scan(&word.Term, &word.Dict); err != nil {
+			return nil, fmt.Errorf("could not scan row: %w", err)
+		}
+		words = append(words, word)
+	}
+	return words, rows.Err()
+}
+
+// getTopTranslations returns the first distinct translations of the entries
+// of a result, leaving the examples out.
+func getTopTranslations(translations TranslationResponse, max int) []string {
+	var targets []string
+	add := func(target string) {
+		target = strings.Join(strings.Fields(plainText(target)), " ")
+		if target != "" && len(targets) < max && !slices.Contains(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+	for _, lang := range translations {
+		for _, hit := range lang.Hits {
+			if len(hit.Roms) == 0 {
+				add(hit.Target)
+			}
+			for _, rom := range hit.Roms {
+				for _, arab := range rom.Arabs {
+					for _, translation := range arab.Translations {
+						if !isExample(translation.Source) {
+							add(translation.Target)
+						}
+					}
+				}
+			}
+		}
+	}
+	return targets
+}
+
+// writeDigest writes the Markdown digest of the words looked up during
+// period, grouped by dictionary, with their top translations.
+func writeDigest(w io.Writer, period string, now time.Time) error {
+	start := getDigestStart(period, now)
+	words, err := getDigestWords(start)
+	if err != nil {
+		return err
+	}
+
+	title := "Words of " + start.Format(config.DateFormat)
+	if period == "week" {
+		title = "Words of the week of " + start.Format(config.DateFormat)
+	}
+	fmt.Fprintf(w, "## %s\n\n", title)
+	if len(words) == 0 {
+		fmt.Fprintf(w, "No words looked up.\n\n")
+		return nil
+	}
+
+	var dicts []string
+	for _, word := range words {
+		if !slices.Contains(dicts, word.Dict) {
+			dicts = append(dicts, word.Dict)
+		}
+	}
+	for _, dict := range dicts {
+		if len(dicts) > 1 {
+			fmt.Fprintf(w, "### %s\n\n", dict)
+		}
+		for _, word := range words {
+			if word.Dict != dict {
+				continue
+			}
+			translations, err := getTranslation(word.Term, word.Dict)
+			if err != nil {
+				// Log the error, but don't fail the digest
+				slog.Warn("could not translate", "term", word.Term, "err", err)
+				fmt.Fprintf(w, "- **%s**\n", word.Term)
+				continue
+			}
+			fmt.Fprintf(w, "- **%s** — %s\n", word.Term, strings.Join(getTopTranslations(translations, digestTranslations), "; "))
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// runDigest writes the digest of period to file, which it's appended to, or
+// prints it when file is empty.
+func runDigest(period, file string) error {
+	if !slices.Contains(digestPeriods, period) {
+		return fmt.Errorf("unknown period %s, expected %s", period, strings.Join(digestPeriods, " or "))
+	}
+	if file == "" {
+		return writeDigest(os.Stdout, period, time.Now())
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open digest file: %w", err)
+	}
+	if err := writeDigest(f, period, time.Now()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("could not write digest file: %w", err)
+	}
+	printNotice("Digest appended to %s\n", file)
+	return nil
+}
+
+// handleDigestCommand writes the digest of today or of the week, to the
+// file given or digest_file.
+func handleDigestCommand(args []string) error {
+	if len(args) > 2 {
+		return fmt.Errorf("usage: .digest [today|week] [<file>]")
+	}
+	period, file := "today", config.DigestFile
+	if len(args) > 0 {
+		period = args[0]
+	}
+	if len(args) > 1 {
+		file = args[1]
+	}
+	return runDigest(period, file)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+)
+
+// sourceLang is the language queries are looked up from in the current
+// dictionary, set by .swap. When empty, they're looked up in both
+// directions.
+var sourceLang string
+
+// getSourceLang returns the language queries are looked up from in dict, or
+// "" to look them up in both directions.
+func getSourceLang(dict string) string {
+	if dict != currentDict {
+		return ""
+	}
+	return sourceLang
+}
+
+// setCurrentDict selects a dictionary, looked up in both directions.
+func setCurrentDict(dict string) {
+	currentDict = dict
+	sourceLang = ""
+}
+
+// selectDirection returns a copy of translations keeping only the entries
+// from the source language of dict, for the providers which can't be asked
+// for a single direction.
+func selectDirection(translations TranslationResponse, dict string) TranslationResponse {
+	source := getSourceLang(dict)
+	if source == "" {
+		return translations
+	}
+	var selected TranslationResponse
+	for _, lang := range translations {
+		if lang.Lang == source {
+			selected = append(selected, lang)
+		}
+	}
+	return selected
+}
+
+// getPromptLabel returns the dictionary shown in the REPL prompt, with the
+// direction of the lookups if one was picked, like "de→en".
+func getPromptLabel() string {
+	if sourceLang == "" || len(currentDict) != 4 {
+		return currentDict
+	}
+	return fmt.Sprintf("%s→%s", sourceLang, getTargetLang(currentDict, sourceLang))
+}
+
+// handleSwapCommand flips the direction of the lookups in the current
+// dictionary, or looks them up in both directions again with "both".
+func handleSwapCommand(args []string) error {
+	if len(args) > 1 || (len(args) == 1 && args[0] != "both") {
+		return fmt.Errorf("usage: .swap [both]")
+	}
+	if currentDict == "" {
+		return fmt.Errorf("no dictionary selected. Use .dict <key> to select one")
+	}
+	if len(currentDict) != 4 {
+		return fmt.Errorf("dictionary %s has no direction", currentDict)
+	}
+
+	switch {
+	case len(args) == 1:
+		sourceLang = ""
+	case sourceLang == currentDict[2:]:
+		sourceLang = currentDict[:2]
+	default:
+		// The first .swap flips the usual reading of the key, e.g. deen is
+		// German to English
+		sourceLang = currentDict[2:]
+	}
+	if sourceLang == "" {
+		printNotice("Looking up %s in both directions\n", currentDict)
+	} else {
+		printNotice("Looking up %s\n", getPromptLabel())
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// countEntries returns the number of entries (roms) of a result, which are
+// numbered across its languages and sections for .show #<n>.
+func countEntries(translations TranslationResponse) int {
+	count := 0
+	for _, lang := range translations {
+		sections, _ := groupByWordClass(lang.Hits)
+		for _, s := range sections {
+			count += len(s.Roms)
+		}
+	}
+	return count
+}
+
+// getEntry returns a result made of the nth entry of translations only,
+// counted from 1 in the order they're displayed.
+func getEntry(translations TranslationResponse, n int) (TranslationResponse, Rom, bool) {
+	for _, lang := range translations {
+		sections, _ := groupByWordClass(lang.Hits)
+		for _, s := range sections {
+			if n > len(s.Roms) {
+				n -= len(s.Roms)
+				continue
+			}
+			rom := s.Roms[n-1]
+			entry := lang
+			entry.Hits = []Hit{{Roms: []Rom{rom}}}
+			return TranslationResponse{entry}, rom, true
+		}
+	}
+	return nil, Rom{}, false
+}
+
+// getNumberedEntries returns translations sorted, without the entries
+// hidden because show_examples is off, so that they're numbered like
+// they're displayed. The entries kept keep their examples.
+func getNumberedEntries(translations TranslationResponse) TranslationResponse {
+	translations = sortTranslations(translations)
+	if config.ShowExamples {
+		return translations
+	}
+	var selected TranslationResponse
+	for _, lang := range translations {
+		var hits []Hit
+		for _, hit := range lang.Hits {
+			var roms []Rom
+			for _, rom := range hit.Roms {
+				// Entries made of examples only aren't displayed
+				if len(selectExamples(TranslationResponse{{Hits: []Hit{{Roms: []Rom{rom}}}}}, false)) > 0 {
+					roms = append(roms, rom)
+				}
+			}
+			if len(roms) > 0 {
+				hit.Roms = roms
+				hits = append(hits, hit)
+			}
+		}
+		if len(hits) > 0 {
+			lang.Hits = hits
+			selected = append(selected, lang)
+		}
+	}
+	return selected
+}
+
+// getPlainHeadword returns the headword of an entry as text, without its
+// syllable marks, keeping its case unlike normalizeHeadword.
+func getPlainHeadword(rom Rom) string {
+	headword := strings.NewReplacer("·", "", "|", "").Replace(plainText(rom.Headword))
+	return strings.Join(strings.Fields(headword), " ")
+}
+
+// formatEntryNumber returns the number shown after the headword of an entry,
+// which results with a single entry don't need.
+func formatEntryNumber(n, count int) string {
+	if count < 2 {
+		return ""
+	}
+	return color.New(color.Faint).Sprintf(" #%d", n)
+}
+
+// showEntry displays the nth entry of the last result alone, with its
+// phonetics and all its examples.
+func showEntry(result *lookupResult, arg string) error {
+	// Entries are numbered in the order they're displayed
+	entries := getNumberedEntries(result.Translations)
+	n, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+	if err != nil || n < 1 || n > countEntries(entries) {
+		return fmt.Errorf("no such entry: %s", arg)
+	}
+	entry, rom, _ := getEntry(entries, n)
+
+	// The section of the entry is expanded, whichever .show <n> picked
+	shown := shownSection.Swap(allSections)
+	defer shownSection.Store(shown)
+
+	var buf bytes.Buffer
+	renderEntry(&buf, entry, rom, result.Dict, result.Word)
+	fmt.Fprint(color.Output, buf.String())
+	return nil
+}
+
+// renderEntry renders a single entry in the output format, regardless of
+// show_examples.
+func renderEntry(w io.Writer, entry TranslationResponse, rom Rom, dict, word string) {
+	format := outputFormat
+	if config.Accessible && format == "table" {
+		format = "accessible"
+	}
+	if full := strings.TrimSpace(parseHTML(rom.HeadwordFull)); full != "" && format == "table" {
+		color.New(color.FgYellow, color.Bold).Fprintf(w, "\n%s\n", full)
+	}
+	getRenderer(format).Render(w, entry, dict, word, getRenderOptions())
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// lookupEvent is a line of the event log.
+type lookupEvent struct {
+	Term      string    `json:"term"`
+	Dict      string    `json:"dict"`
+	Timestamp time.Time `json:"timestamp"`
+	CacheHit  bool      `json:"cache_hit"`
+	Found     bool      `json:"found"`
+}
+
+var eventLogMu sync.Mutex
+
+// logLookupEvent appends a lookup to the event log, as a JSON line, when
+// event_log is set, cached telling whether the entries were served from
+// the cache.
+func logLookupEvent(term, dict string, found, cached bool) {
+	if config.EventLog == "" {
+		return
+	}
+	event := lookupEvent{Term: term, Dict: dict, Timestamp: time.Now(), CacheHit: cached, Found: found}
+	if err := appendEvent(config.EventLog, event); err != nil {
+		// Log the error, but don't fail the lookup
+		slog.Warn("could not write event log", "err", err)
+	}
+}
+
+func appendEvent(path string, event any) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("could not marshal event: %w", err)
+	}
+
+	eventLogMu.Lock()
+	defer eventLogMu.Unlock()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	// Lines are written at once, so that processes sharing the log don't
+	// mix them up
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
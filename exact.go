@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+)
+
+// normalizeHeadword returns a headword without the syllable separators and
+// the case PONS headwords may have, to compare it with a query.
+func normalizeHeadword(headword string) string {
+	headword = strings.NewReplacer("·", "", "|", "").Replace(plainText(headword))
+	return strings.ToLower(strings.Join(strings.Fields(headword), " "))
+}
+
+// selectExactMatches returns a copy of translations keeping only the
+// entries whose headword is word, rather than merely containing it.
+func selectExactMatches(translations TranslationResponse, word string) (TranslationResponse, error) {
+	word = normalizeHeadword(word)
+	var selected TranslationResponse
+	for _, lang := range translations {
+		var hits []Hit
+		for _, hit := range lang.Hits {
+			if len(hit.Roms) == 0 {
+				if normalizeHeadword(hit.Source) == word {
+					hits = append(hits, hit)
+				}
+				continue
+			}
+			var roms []Rom
+			for _, rom := range hit.Roms {
+				if normalizeHeadword(rom.Headword) == word {
+					roms = append(roms, rom)
+				}
+			}
+			if len(roms) > 0 {
+				hit.Roms = roms
+				hits = append(hits, hit)
+			}
+		}
+		if len(hits) > 0 {
+			lang.Hits = hits
+			selected = append(selected, lang)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, errNoTranslation
+	}
+	return selected, nil
+}
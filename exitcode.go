@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/fatih/color"
+)
+
+// Exit codes of one-shot lookups and --exec, so that scripts can tell the
+// failures apart.
+const (
+	exitError       = 1
+	exitNotFound    = 2
+	exitAuthFailure = 3
+	exitNetworkErr  = 4
+)
+
+// quietOutput suppresses the welcome banner and informational notices.
+var quietOutput bool
+
+// lastCommandErr is the error of the last failed command, which gives the
+// exit code of --exec.
+var lastCommandErr error
+
+// getErrorKind classifies an error as "not_found", "auth", "network" or
+// "error", along with its exit code.
+func getErrorKind(err error) (string, int) {
+	var urlErr *url.Error
+	switch {
+	case errors.Is(err, errNoTranslation):
+		return "not_found", exitNotFound
+	case getStatusCode(err) == http.StatusUnauthorized || getStatusCode(err) == http.StatusForbidden:
+		return "auth", exitAuthFailure
+	case errors.As(err, &urlErr):
+		return "network", exitNetworkErr
+	}
+	return "error", exitError
+}
+
+// printError prints the error of a command, as a JSON object when the JSON
+// output format is selected.
+func printError(err error) {
+	lastCommandErr = err
+	if outputFormat == "json" {
+		kind, code := getErrorKind(err)
+		body, _ := json.Marshal(map[string]any{"error": err.Error(), "kind": kind, "exit_code": code})
+		fmt.Println(string(body))
+		return
+	}
+	color.New(color.FgRed, color.Bold).Println("Error:", err)
+}
+
+// exitOnError exits with the exit code of err, if any.
+func exitOnError(err error) {
+	if err == nil {
+		return
+	}
+	_, code := getErrorKind(err)
+	waitHooks()
+	os.Exit(code)
+}
+
+// printNotice prints an informational message, unless in quiet mode.
+func printNotice(format string, a ...any) {
+	if quietOutput {
+		return
+	}
+	color.New(color.FgYellow).Printf(format, a...)
+}
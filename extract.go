@@ -0,0 +1,296 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/fatih/color"
+	"golang.org/x/net/html"
+)
+
+var srtTimestamp = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}[,.]\d{3}\s*-->`)
+var srtTag = regexp.MustCompile(`<[^>]*>|\{[^}]*\}`)
+
+type wordCount struct {
+	Word  string
+	Count int
+}
+
+// runExtract ranks the words of a text, EPUB or SRT file by frequency,
+// leaving out the ones already looked up, and offers to look up the most
+// frequent ones so that they can be studied with .cards.
+func runExtract(args []string) error {
+	flags := flag.NewFlagSet("extract", flag.ExitOnError)
+	top := flags.Int("top", 20, "number of words to keep")
+	dict := flags.String("dict", currentDict, "dictionary to translate the words with, e.g. deen")
+	level := flags.String("level", "", "keep only the words of a CEFR band like B1, or a range like A2-B1")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: pons-cli extract [--top <n>] [--dict <key>] [--level <band>] <file>")
+	}
+
+	// The bands are approximated from the frequency list of the language
+	var low, high int
+	if *level != "" {
+		var err error
+		if low, high, err = parseCEFRRange(*level); err != nil {
+			return err
+		}
+		if len(*dict) < 2 {
+			return fmt.Errorf("--level needs --dict <key> to know the language of the words")
+		}
+		if !hasFrequencyList((*dict)[:2]) {
+			return fmt.Errorf("no frequency list for %s, save one as %s", (*dict)[:2], getFrequencyListFile((*dict)[:2]))
+		}
+	}
+
+	text, err := readExtractFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	known, err := getSearchedTerms(*dict)
+	if err != nil {
+		return err
+	}
+
+	words := rankWords(text, known)
+	if *level != "" {
+		words = slices.DeleteFunc(words, func(w wordCount) bool {
+			l := getWordLevel(w.Word, (*dict)[:2])
+			return l < low || l > high
+		})
+	}
+	if len(words) > *top {
+		words = words[:*top]
+	}
+	if len(words) == 0 {
+		color.New(color.FgYellow).Println("No new words found.")
+		return nil
+	}
+
+	for i, word := range words {
+		color.New(color.FgGreen).Printf("%3d. %s", i+1, word.Word)
+		fmt.Printf(" (%d)\n", word.Count)
+	}
+
+	if *dict == "" {
+		color.New(color.FgYellow).Println("Use --dict <key> to translate these words.")
+		return nil
+	}
+
+	answer, _ := readAnswer(color.YellowString("Translate these %d words and add them to your history for .cards? [y/N] ", len(words)))
+	if strings.ToLower(answer) != "y" {
+		return nil
+	}
+
+	added := 0
+	for i, word := range words {
+		fmt.Printf("[%d/%d] %s", i+1, len(words), word.Word)
+		_, cached, err := lookupTranslation(word.Word, *dict)
+		if err != nil {
+			color.New(color.FgRed).Printf(" %v\n", err)
+			continue
+		}
+		if err := addSearchHistory(word.Word, *dict, cached); err != nil {
+			return err
+		}
+		added++
+		fmt.Println()
+	}
+	color.New(color.FgYellow).Printf("%d words added.\n", added)
+
+	return nil
+}
+
+func readExtractFile(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".epub":
+		return readEPUB(path)
+	case ".srt":
+		return readSRT(path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read file: %w", err)
+	}
+	return string(content), nil
+}
+
+// readEPUB returns the text of the (X)HTML documents of an EPUB archive.
+func readEPUB(path string) (string, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return "", fmt.Errorf("could not open epub: %w", err)
+	}
+	defer archive.Close()
+
+	var sb strings.Builder
+	for _, file := range archive.File {
+		ext := strings.ToLower(filepath.Ext(file.Name))
+		if ext != ".xhtml" && ext != ".html" && ext != ".htm" {
+			continue
+		}
+		content, err := readZipFile(file)
+		if err != nil {
+			return "", fmt.Errorf("could not read %s from epub: %w", file.Name, err)
+		}
+		sb.WriteString(htmlText(content))
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+func readZipFile(file *zip.File) (string, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// htmlText returns the text of an HTML document, one line per text node.
+func htmlText(content string) string {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+	var sb strings.Builder
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style" || n.Data == "head") {
+			return
+		}
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			sb.WriteString("\n")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(doc)
+	return sb.String()
+}
+
+// readSRT returns the subtitle lines of an SRT file, without the cue numbers,
+// timestamps and formatting tags.
+func readSRT(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("could not open file: %w", err)
+	}
+	defer file.Close()
+
+	var sb strings.Builder
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || srtTimestamp.MatchString(line) || isNumber(line) {
+			continue
+		}
+		sb.WriteString(srtTag.ReplaceAllString(line, ""))
+		sb.WriteString("\n")
+	}
+	return sb.String(), scanner.Err()
+}
+
+func isNumber(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// rankWords counts the words of text, case-insensitively, and sorts them by
+// decreasing frequency. Each word is spelled the way it appears most often,
+// so that German nouns keep their capital. Words in known are left out.
+func rankWords(text string, known map[string]bool) []wordCount {
+	counts := map[string]int{}
+	spellings := map[string]map[string]int{}
+
+	tokens := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\'' && r != '’' && r != '-'
+	})
+	for _, token := range tokens {
+		token = strings.Trim(token, "'’-")
+		if len([]rune(token)) < 2 {
+			continue
+		}
+		key := strings.ToLower(token)
+		if known[key] {
+			continue
+		}
+		counts[key]++
+		if spellings[key] == nil {
+			spellings[key] = map[string]int{}
+		}
+		spellings[key][token]++
+	}
+
+	words := make([]wordCount, 0, len(counts))
+	for key, count := range counts {
+		best, bestCount := key, 0
+		for spelling, n := range spellings[key] {
+			if n > bestCount || (n == bestCount && spelling < best) {
+				best, bestCount = spelling, n
+			}
+		}
+		words = append(words, wordCount{Word: best, Count: count})
+	}
+
+	sort.Slice(words, func(i, j int) bool {
+		if words[i].Count != words[j].Count {
+			return words[i].Count > words[j].Count
+		}
+		return words[i].Word < words[j].Word
+	})
+	return words
+}
+
+// getSearchedTerms returns the lowercased terms of the search history, for
+// the given dictionary or all of them if dict is empty.
+func getSearchedTerms(dict string) (map[string]bool, error) {
+	query := "SELECT DISTINCT searched_term FROM search_history"
+	var args []interface{}
+	if dict != "" {
+		query += " WHERE dict = ?"
+		args = append(args, dict)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query search history: %w", err)
+	}
+	defer rows.Close()
+
+	terms := map[string]bool{}
+	for rows.Next() {
+		var term string
+		if err := rows.Scan(&term); err != nil {
+			return nil, fmt.Errorf("could not scan row: %w", err)
+		}
+		terms[strings.ToLower(term)] = true
+	}
+	return terms, rows.Err()
+}
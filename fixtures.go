@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+)
+
+// recordFixturesDir and replayFixturesDir are set by --record-fixtures and
+// --replay-fixtures.
+var (
+	recordFixturesDir string
+	replayFixturesDir string
+)
+
+// fixtureTransport saves the API responses to a directory, or serves them
+// from it without network, for tests and demos.
+type fixtureTransport struct {
+	dir    string
+	replay bool
+	base   http.RoundTripper
+}
+
+// getFixtureFile returns the fixture file of a request, named after its
+// method, URL and body. Headers, which carry the API keys, are left out.
+func getFixtureFile(dir string, req *http.Request) (string, error) {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", req.Method, req.URL)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return "", fmt.Errorf("could not read request body: %w", err)
+		}
+		defer body.Close()
+		if _, err := io.Copy(hash, body); err != nil {
+			return "", fmt.Errorf("could not read request body: %w", err)
+		}
+	}
+	return filepath.Join(dir, hex.EncodeToString(hash.Sum(nil))[:32]+".http"), nil
+}
+
+func (t fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fixtureFile, err := getFixtureFile(t.dir, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.replay {
+		data, err := os.ReadFile(fixtureFile)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no fixture for %s %s", req.Method, req.URL.Redacted())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read fixture: %w", err)
+		}
+		return http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	// The body stays readable after the dump
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("could not dump response: %w", err)
+	}
+	if err := os.WriteFile(fixtureFile, dump, 0644); err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("could not write fixture: %w", err)
+	}
+	return resp, nil
+}
+
+func usingFixtures() bool {
+	return recordFixturesDir != "" || replayFixturesDir != ""
+}
+
+// setupFixtures checks the fixture flags.
+func setupFixtures() error {
+	if recordFixturesDir != "" && replayFixturesDir != "" {
+		return fmt.Errorf("--record-fixtures and --replay-fixtures can't be used together")
+	}
+	if recordFixturesDir != "" {
+		if err := os.MkdirAll(recordFixturesDir, 0755); err != nil {
+			return fmt.Errorf("could not create fixtures dir: %w", err)
+		}
+	}
+	if replayFixturesDir != "" {
+		if _, err := os.Stat(replayFixturesDir); err != nil {
+			return fmt.Errorf("could not open fixtures dir: %w", err)
+		}
+	}
+	return nil
+}
+
+// wrapFixtureTransport records or replays the responses of base if a
+// fixture flag is given.
+func wrapFixtureTransport(base http.RoundTripper) http.RoundTripper {
+	switch {
+	case recordFixturesDir != "":
+		return fixtureTransport{dir: recordFixturesDir, base: base}
+	case replayFixturesDir != "":
+		return fixtureTransport{dir: replayFixturesDir, replay: true}
+	}
+	return base
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"golang.org/x/net/html"
+)
+
+// maxCardRows bounds the translations put on the back of a card.
+const maxCardRows = 8
+
+// clozeBlank replaces the word in the sentences of cloze cards.
+const clozeBlank = "_____"
+
+// flashcard is a card of .review. Word cards show a word on the front and
+// its translations on the back, cloze cards an example phrase with the word
+// blanked out on the front.
+type flashcard struct {
+	ID          int64
+	Word        string
+	Dict        string
+	Kind        string
+	Deck        string
+	Note        string
+	Tags        string
+	Front       string
+	Back        string
+	Due         time.Time
+	Interval    int
+	Ease        float64
+	Repetitions int
+	// New tells whether the card was never reviewed.
+	New bool
+}
+
+// getTranslationLines returns the first translations of a lookup result as
+// "source — target" lines of plain text.
+func getTranslationLines(translations TranslationResponse, max int) []string {
+	var lines []string
+	add := func(source, target string) {
+		if len(lines) < max {
+			lines = append(lines, strings.Join(strings.Fields(plainText(source)), " ")+" — "+strings.Join(strings.Fields(plainText(target)), " "))
+		}
+	}
+	for _, lang := range translations {
+		for _, hit := range lang.Hits {
+			if len(hit.Roms) == 0 {
+				add(hit.Source, hit.Target)
+			}
+			for _, rom := range hit.Roms {
+				for _, arab := range rom.Arabs {
+					for _, translation := range arab.Translations {
+						add(translation.Source, translation.Target)
+					}
+				}
+			}
+		}
+	}
+	return lines
+}
+
+// blankWord blanks the word out of an example phrase, as well as the forms
+// PONS marks as headword or tilde, which may be inflected. It returns false
+// when there was nothing to blank out.
+func blankWord(source, word string) (string, bool) {
+	doc, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return "", false
+	}
+	// \b only knows ASCII letters, which would break on umlauts
+	wordRe := regexp.MustCompile(`(?i)(^|\P{L})` + regexp.QuoteMeta(word) + `(\P{L}|$)`)
+	blanked := false
+	var sb strings.Builder
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "strong" && (hasClass(n, "headword") || hasClass(n, "tilde")) {
+			sb.WriteString(clozeBlank)
+			blanked = true
+			return
+		}
+		if n.Type == html.TextNode {
+			if wordRe.MatchString(n.Data) {
+				blanked = true
+			}
+			sb.WriteString(wordRe.ReplaceAllString(n.Data, "${1}"+clozeBlank+"${2}"))
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(doc)
+	return strings.Join(strings.Fields(sb.String()), " "), blanked
+}
+
+// getClozeCards makes a cloze card of each example phrase of a lookup
+// result containing the word.
+func getClozeCards(word, dict string, translations TranslationResponse) []flashcard {
+	var cards []flashcard
+	for _, lang := range translations {
+		for _, hit := range lang.Hits {
+			for _, rom := range hit.Roms {
+				for _, arab := range rom.Arabs {
+					for _, translation := range arab.Translations {
+						if !isExample(translation.Source) {
+							continue
+						}
+						blanked, ok := blankWord(translation.Source, word)
+						if !ok {
+							continue
+						}
+						cards = append(cards, flashcard{
+							Word:  word,
+							Dict:  dict,
+							Kind:  "cloze",
+							Front: blanked + "\n(" + glossaryText(translation.Target) + ")",
+							Back:  glossaryText(translation.Source),
+						})
+					}
+				}
+			}
+		}
+	}
+	return cards
+}
+
+// addFlashcard adds a card, due right away. A card already saved is left
+// as it is, with its scheduling.
+func addFlashcard(card flashcard) (bool, error) {
+	now := time.Now()
+	result, err := db.Exec(`
+		INSERT INTO flashcards(word, dict, kind, deck, note, tags, front, back, due, created) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(dict, kind, front) DO NOTHING
+	`, card.Word, card.Dict, card.Kind, card.Deck, card.Note, card.Tags, card.Front, card.Back, now, now)
+	if err != nil {
+		return false, fmt.Errorf("could not save flashcard: %w", err)
+	}
+	added, err := result.RowsAffected()
+	return added > 0, err
+}
+
+// findWordCard returns the card of a word saved in dict, whatever its
+// case, or false if it isn't saved.
+func findWordCard(word, dict string) (flashcard, bool, error) {
+	card := flashcard{Word: word, Dict: dict, Kind: "word"}
+	err := db.QueryRow(`
+		SELECT id, word, deck, note, tags FROM flashcards
+		WHERE kind = 'word' AND dict = ? AND LOWER(word) = LOWER(?)
+	`, dict, word).Scan(&card.ID, &card.Word, &card.Deck, &card.Note, &card.Tags)
+	if err == sql.ErrNoRows {
+		return card, false, nil
+	}
+	if err != nil {
+		return card, false, fmt.Errorf("could not query flashcards: %w", err)
+	}
+	return card, true, nil
+}
+
+// mergeWordCard adds a note and tags to the card of a saved word, the note
+// on a line of its own unless the card has it already. It tells whether the
+// card changed.
+func mergeWordCard(card flashcard, note string, tags []string) (bool, error) {
+	merged := card.Note
+	if note != "" && !slices.Contains(strings.Split(card.Note, "\n"), note) {
+		merged = strings.TrimPrefix(card.Note+"\n"+note, "\n")
+	}
+	mergedTags := strings.Fields(card.Tags)
+	for _, tag := range tags {
+		if !slices.Contains(mergedTags, tag) {
+			mergedTags = append(mergedTags, tag)
+		}
+	}
+	if merged == card.Note && strings.Join(mergedTags, " ") == card.Tags {
+		return false, nil
+	}
+
+	if _, err := db.Exec("UPDATE flashcards SET note = ?, tags = ? WHERE id = ?", merged, strings.Join(mergedTags, " "), card.ID); err != nil {
+		return false, fmt.Errorf("could not update flashcard: %w", err)
+	}
+	return true, nil
+}
+
+// saveWord saves the card of a word to deck, or if empty to the deck of the
+// first deck rule it matches or else to the default deck of dict, along
+// with cloze cards of its examples when enabled. It returns the number of
+// cards added, none if the word is saved already, whatever its case.
+func saveWord(word, dict, deck string, translations TranslationResponse) (int, error) {
+	return saveWordCards(flashcard{Word: word, Dict: dict, Deck: deck}, translations)
+}
+
+// saveWordCards saves the cards of a word like saveWord, with the deck,
+// note and tags of card.
+func saveWordCards(card flashcard, translations TranslationResponse) (int, error) {
+	if _, found, err := findWordCard(card.Word, card.Dict); err != nil || found {
+		return 0, err
+	}
+	// The deck asked for comes first, then the deck rules, then the default
+	// deck of the dictionary
+	if card.Deck == "" {
+		if deck, ok := matchDeckRule(card, translations); ok {
+			card.Deck = deck
+		} else {
+			card.Deck = getDefaultDeck(card.Dict)
+		}
+	}
+	if err := ensureDeck(card.Deck); err != nil {
+		return 0, err
+	}
+
+	card.Kind = "word"
+	card.Front = card.Word
+	card.Back = strings.Join(getTranslationLines(translations, maxCardRows), "\n")
+	cards := []flashcard{card}
+	if config.ClozeCards {
+		for _, cloze := range getClozeCards(card.Word, card.Dict, translations) {
+			cloze.Deck = card.Deck
+			cards = append(cards, cloze)
+		}
+	}
+
+	added := 0
+	for _, card := range cards {
+		ok, err := addFlashcard(card)
+		if err != nil {
+			return added, err
+		}
+		if ok {
+			added++
+		}
+	}
+	return added, nil
+}
+
+// handleSaveCommand saves the last looked up word as a flashcard, along
+// with cloze cards of its examples when enabled.
+func handleSaveCommand() error {
+	result := getLastResult()
+	if result == nil {
+		return fmt.Errorf("no previous lookup")
+	}
+
+	added, err := saveWord(result.Word, result.Dict, "", result.Translations)
+	if err != nil {
+		return err
+	}
+
+	if added == 0 {
+		color.New(color.FgYellow).Printf("%s is already saved\n", result.Word)
+		return nil
+	}
+	if added == 1 {
+		color.New(color.FgGreen).Printf("Saved %s\n", result.Word)
+	} else {
+		color.New(color.FgGreen).Printf("Saved %s (%d cards)\n", result.Word, added)
+	}
+	return nil
+}
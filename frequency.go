@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/fatih/color"
+)
+
+// frequencyAnnotations are the values of frequency_annotation.
+var frequencyAnnotations = []string{"off", "rank", "cefr"}
+
+// cefrLevels are the CEFR bands approximated from the frequency rank of
+// words: a word ranked up to MaxRank is about as hard as the band, the last
+// band taking all the rarer words.
+var cefrLevels = []struct {
+	Name    string
+	MaxRank int
+}{
+	{"A1", 500},
+	{"A2", 1000},
+	{"B1", 2000},
+	{"B2", 4000},
+	{"C1", 8000},
+	{"C2", 0},
+}
+
+var (
+	frequencyListsMu sync.Mutex
+	frequencyLists   = map[string]map[string]int{}
+)
+
+// getFrequencyListFile returns the frequency list of lang, which users
+// download, e.g. from the FrequencyWords project.
+func getFrequencyListFile(lang string) string {
+	return filepath.Join(getAppDataDir(), "frequency", lang+".txt")
+}
+
+// getFrequencyList returns the rank of the words of the frequency list of
+// lang, read once. The list has a word per line, the most frequent first,
+// possibly followed by its count. A missing list is empty.
+func getFrequencyList(lang string) map[string]int {
+	frequencyListsMu.Lock()
+	defer frequencyListsMu.Unlock()
+	if ranks, ok := frequencyLists[lang]; ok {
+		return ranks
+	}
+
+	ranks := map[string]int{}
+	frequencyLists[lang] = ranks
+	file, err := os.Open(getFrequencyListFile(lang))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			// Log the error, but don't fail the lookup
+			slog.Warn("could not open frequency list", "lang", lang, "err", err)
+		}
+		return ranks
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		word := strings.ToLower(fields[0])
+		if _, ok := ranks[word]; !ok {
+			ranks[word] = len(ranks) + 1
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		// Log the error, but don't fail the lookup
+		slog.Warn("could not read frequency list", "lang", lang, "err", err)
+	}
+	return ranks
+}
+
+// getFrequencyRank returns the rank of word in the frequency list of lang.
+func getFrequencyRank(word, lang string) (int, bool) {
+	rank, ok := getFrequencyList(lang)[strings.ToLower(word)]
+	return rank, ok
+}
+
+// hasFrequencyList tells whether the frequency list of lang was found.
+func hasFrequencyList(lang string) bool {
+	return len(getFrequencyList(lang)) > 0
+}
+
+// getCEFRLevel returns the index in cefrLevels of the band of a rank.
+func getCEFRLevel(rank int) int {
+	for i, level := range cefrLevels {
+		if rank <= level.MaxRank {
+			return i
+		}
+	}
+	return len(cefrLevels) - 1
+}
+
+// parseCEFRLevel returns the index in cefrLevels of a band like "b1".
+func parseCEFRLevel(s string) (int, bool) {
+	for i, level := range cefrLevels {
+		if strings.EqualFold(s, level.Name) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// parseCEFRRange returns the bands of a level like "B1", or a range like
+// "A2-B1".
+func parseCEFRRange(s string) (int, int, error) {
+	from, to, isRange := strings.Cut(s, "-")
+	if !isRange {
+		to = from
+	}
+	low, ok1 := parseCEFRLevel(from)
+	high, ok2 := parseCEFRLevel(to)
+	if !ok1 || !ok2 || low > high {
+		return 0, 0, fmt.Errorf("invalid level %s, expected a CEFR band like B1 or a range like A2-B1", s)
+	}
+	return low, high, nil
+}
+
+// getWordLevel returns the band of a word, words missing from the frequency
+// list being rarer than all of those listed.
+func getWordLevel(word, lang string) int {
+	rank, ok := getFrequencyRank(word, lang)
+	if !ok {
+		return len(cefrLevels) - 1
+	}
+	return getCEFRLevel(rank)
+}
+
+// formatFrequency returns the frequency rank or the CEFR band shown after
+// the headword of an entry, as frequency_annotation says. Headwords of
+// several words have none.
+func formatFrequency(rom Rom, lang string) string {
+	if config.FrequencyAnnotation == "off" || config.FrequencyAnnotation == "" {
+		return ""
+	}
+	headword := normalizeHeadword(rom.Headword)
+	if strings.IndexFunc(headword, unicode.IsSpace) >= 0 {
+		return ""
+	}
+	rank, ok := getFrequencyRank(headword, lang)
+	if !ok {
+		return ""
+	}
+	if config.FrequencyAnnotation == "rank" {
+		return color.New(color.Faint).Sprintf(" ~%d", rank)
+	}
+	return color.New(color.Faint).Sprintf(" %s", cefrLevels[getCEFRLevel(rank)].Name)
+}
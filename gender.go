@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+var genusPattern = regexp.MustCompile(`<span class="genus">(.*?)</span>`)
+
+var colorNames = map[string]color.Attribute{
+	"black":   color.FgBlack,
+	"red":     color.FgRed,
+	"green":   color.FgGreen,
+	"yellow":  color.FgYellow,
+	"blue":    color.FgBlue,
+	"magenta": color.FgMagenta,
+	"cyan":    color.FgCyan,
+	"white":   color.FgWhite,
+}
+
+// getGender normalizes a genus marker (m, fem, neuter, der...) into "m", "f"
+// or "n", or returns "" if it isn't one.
+func getGender(marker string) string {
+	switch strings.ToLower(strings.Trim(strings.TrimSpace(marker), ".")) {
+	case "m", "masc", "masculine", "der":
+		return "m"
+	case "f", "fem", "feminine", "die":
+		return "f"
+	case "n", "nt", "neut", "neuter", "das":
+		return "n"
+	}
+	return ""
+}
+
+// parseGenderPalette parses a palette like "m=blue,f=red,n=green".
+func parseGenderPalette(palette string) (map[string]color.Attribute, error) {
+	colors := map[string]color.Attribute{}
+	for _, item := range strings.Split(palette, ",") {
+		marker, name, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, fmt.Errorf("expected <gender>=<color>: %s", item)
+		}
+		gender := getGender(marker)
+		if gender == "" {
+			return nil, fmt.Errorf("unknown gender: %s", marker)
+		}
+		attr, ok := colorNames[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown color: %s", name)
+		}
+		colors[gender] = attr
+	}
+	return colors, nil
+}
+
+func getGenderColor(gender string) (*color.Color, bool) {
+	if gender == "" {
+		return nil, false
+	}
+	palette, err := parseGenderPalette(config.GenderPalette)
+	if err != nil {
+		return nil, false
+	}
+	attr, ok := palette[gender]
+	if !ok {
+		return nil, false
+	}
+	return color.New(attr, color.Bold), true
+}
+
+// getRomGender finds the gender of a headword, from the genus marker of its
+// full headword, or of the first row of its entry.
+func getRomGender(rom Rom) string {
+	candidates := []string{rom.HeadwordFull}
+	if len(rom.Arabs) > 0 && len(rom.Arabs[0].Translations) > 0 {
+		candidates = append(candidates, rom.Arabs[0].Translations[0].Source)
+	}
+	for _, candidate := range candidates {
+		if gender := findGenus(candidate); gender != "" {
+			return gender
+		}
+	}
+
+	// German headwords may be given with their article
+	if article, _, ok := strings.Cut(parseHTML(rom.HeadwordFull), " "); ok {
+		return getGender(article)
+	}
+	return ""
+}
+
+// findGenus returns the gender of the first genus marker of an HTML snippet.
+func findGenus(htmlString string) string {
+	genus := genusPattern.FindStringSubmatch(htmlString)
+	if genus == nil {
+		return ""
+	}
+	return getGender(ansiEscape.ReplaceAllString(parseHTML(genus[1]), ""))
+}
+
+func getHeadwordColor(rom Rom) *color.Color {
+	if config.GenderColors {
+		if c, ok := getGenderColor(getRomGender(rom)); ok {
+			return c
+		}
+	}
+	return color.New(color.FgYellow, color.Bold)
+}
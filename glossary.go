@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// glossaryEntry is a term of a bilingual glossary.
+type glossaryEntry struct {
+	SourceLang string
+	TargetLang string
+	Source     string
+	Target     string
+	WordClass  string
+	Example    string
+	Phonetics  string
+}
+
+func handleExportCommand(args []string) error {
+	if len(args) == 2 && args[0] == "note" {
+		return exportNotes(args[1])
+	}
+	if len(args) > 0 && args[0] == "worksheet" {
+		return handleExportWorksheet(args[1:])
+	}
+	if len(args) == 2 && args[0] == "reviews" {
+		return exportReviews(args[1])
+	}
+	if len(args) < 2 || len(args) > 3 || args[0] != "glossary" || (args[1] != "csv" && args[1] != "tmx") {
+		return fmt.Errorf("usage: .export glossary csv|tmx [<file>], .export note <dir>, .export worksheet <file> [--blank source|target] [--deck <name>] [--tag <tag>] or .export reviews <file>")
+	}
+	if currentDict == "" {
+		return fmt.Errorf("no dictionary selected. Use .dict <key> to select one")
+	}
+
+	format := args[1]
+	path := fmt.Sprintf("glossary-%s.%s", currentDict, format)
+	if len(args) == 3 {
+		path = args[2]
+	}
+
+	entries, err := getGlossary(currentDict)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no words searched in %s", currentDict)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create glossary file: %w", err)
+	}
+	defer file.Close()
+
+	if format == "csv" {
+		err = writeGlossaryCSV(file, entries)
+	} else {
+		err = writeGlossaryTMX(file, entries)
+	}
+	if err != nil {
+		return err
+	}
+
+	color.New(color.FgGreen).Printf("%d terms exported to %s\n", len(entries), path)
+	return nil
+}
+
+// getGlossary builds a glossary of the words searched in dict: the first
+// translation of each of their entries, with an example of their use.
+func getGlossary(dict string) ([]glossaryEntry, error) {
+	terms, err := getSearchedTermsOf(dict)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []glossaryEntry
+	for _, term := range terms {
+		translations, err := getTranslation(term, dict)
+		if err != nil {
+			// Log the error, but don't fail the command
+			slog.Warn("could not translate", "term", term, "err", err)
+			continue
+		}
+		for _, lang := range translations {
+			for _, hit := range lang.Hits {
+				for _, rom := range hit.Roms {
+					entry := glossaryEntry{SourceLang: lang.Lang, TargetLang: getTargetLang(dict, lang.Lang), WordClass: rom.WordClass, Phonetics: getPhonetics(rom)}
+					for _, arab := range rom.Arabs {
+						for _, translation := range arab.Translations {
+							if isExample(translation.Source) {
+								if entry.Example == "" {
+									entry.Example = glossaryText(translation.Source) + " — " + glossaryText(translation.Target)
+								}
+							} else if entry.Source == "" {
+								entry.Source, entry.Target = glossaryText(translation.Source), glossaryText(translation.Target)
+							}
+						}
+					}
+					if entry.Source != "" {
+						entries = append(entries, entry)
+					}
+				}
+			}
+		}
+	}
+	orientGlossary(dict, entries)
+	return entries, nil
+}
+
+// orientGlossary turns the terms searched from the other side of dict, like
+// an English term in deen, the way of dict, so that each column of the
+// glossary is in a single language.
+func orientGlossary(dict string, entries []glossaryEntry) {
+	if len(dict) != 4 {
+		return
+	}
+	for i, entry := range entries {
+		if entry.SourceLang != dict[2:] {
+			continue
+		}
+		entries[i].SourceLang, entries[i].TargetLang = entry.TargetLang, entry.SourceLang
+		entries[i].Source, entries[i].Target = entry.Target, entry.Source
+		if source, target, ok := strings.Cut(entry.Example, " — "); ok {
+			entries[i].Example = target + " — " + source
+		}
+	}
+}
+
+func glossaryText(s string) string {
+	return strings.Join(strings.Fields(plainText(s)), " ")
+}
+
+func writeGlossaryCSV(w io.Writer, entries []glossaryEntry) error {
+	writer := csv.NewWriter(w)
+	header := []string{entries[0].SourceLang, entries[0].TargetLang, "part of speech", "example"}
+	if config.Phonetics {
+		header = append(header, "phonetics")
+	}
+	writer.Write(header)
+	for _, entry := range entries {
+		record := []string{entry.Source, entry.Target, entry.WordClass, entry.Example}
+		if config.Phonetics {
+			record = append(record, entry.Phonetics)
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("could not write glossary: %w", err)
+	}
+	return nil
+}
+
+type tmxDocument struct {
+	XMLName xml.Name  `xml:"tmx"`
+	Version string    `xml:"version,attr"`
+	Header  tmxHeader `xml:"header"`
+	Units   []tmxUnit `xml:"body>tu"`
+}
+
+type tmxHeader struct {
+	CreationTool        string `xml:"creationtool,attr"`
+	CreationToolVersion string `xml:"creationtoolversion,attr"`
+	SegType             string `xml:"segtype,attr"`
+	OTMF                string `xml:"o-tmf,attr"`
+	AdminLang           string `xml:"adminlang,attr"`
+	SrcLang             string `xml:"srclang,attr"`
+	DataType            string `xml:"datatype,attr"`
+}
+
+type tmxUnit struct {
+	Props    []tmxProp    `xml:"prop"`
+	Note     string       `xml:"note,omitempty"`
+	Variants []tmxVariant `xml:"tuv"`
+}
+
+type tmxProp struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type tmxVariant struct {
+	Lang    string `xml:"xml:lang,attr"`
+	Segment string `xml:"seg"`
+}
+
+// writeGlossaryTMX writes a TMX 1.4 file, as read by CAT tools like OmegaT
+// and Trados.
+func writeGlossaryTMX(w io.Writer, entries []glossaryEntry) error {
+	doc := tmxDocument{
+		Version: "1.4",
+		Header: tmxHeader{
+			CreationTool:        "pons-cli",
+			CreationToolVersion: version,
+			SegType:             "phrase",
+			OTMF:                "pons-cli",
+			AdminLang:           "en",
+			SrcLang:             entries[0].SourceLang,
+			DataType:            "plaintext",
+		},
+	}
+	for _, entry := range entries {
+		unit := tmxUnit{
+			Note: entry.Example,
+			Variants: []tmxVariant{
+				{Lang: entry.SourceLang, Segment: entry.Source},
+				{Lang: entry.TargetLang, Segment: entry.Target},
+			},
+		}
+		if entry.WordClass != "" {
+			unit.Props = []tmxProp{{Type: "x-partofspeech", Value: entry.WordClass}}
+		}
+		doc.Units = append(doc.Units, unit)
+	}
+
+	io.WriteString(w, xml.Header)
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("could not write glossary: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
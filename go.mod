@@ -2,24 +2,24 @@ module pons-cli
 
 go 1.24.5
 
-require golang.org/x/term v0.33.0
-
 require (
-	github.com/eiannone/keyboard v0.0.0-20220611211555-0d226195f203 // indirect
-	github.com/mattn/go-sqlite3 v1.14.30 // indirect
+	github.com/eiannone/keyboard v0.0.0-20220611211555-0d226195f203
+	github.com/mattn/go-sqlite3 v1.14.30
+	golang.org/x/term v0.33.0
 )
 
 require (
-	github.com/BurntSushi/toml v1.5.0 // indirect
-	github.com/adrg/xdg v0.5.3 // indirect
+	github.com/BurntSushi/toml v1.5.0
+	github.com/adrg/xdg v0.5.3
 	github.com/chzyer/readline v1.5.1
-	github.com/fatih/color v1.18.0 // indirect
-	github.com/jedib0t/go-pretty/v6 v6.6.7 // indirect
+	github.com/fatih/color v1.18.0
+	github.com/gosimple/unidecode v1.0.1
+	github.com/jedib0t/go-pretty/v6 v6.6.7
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
-	github.com/mattn/go-runewidth v0.0.16 // indirect
+	github.com/mattn/go-runewidth v0.0.16
 	github.com/rivo/uniseg v0.4.7 // indirect
-	golang.org/x/net v0.42.0 // indirect
-	golang.org/x/sys v0.34.0 // indirect
-	golang.org/x/text v0.27.0 // indirect
+	golang.org/x/net v0.42.0
+	golang.org/x/sys v0.34.0
+	golang.org/x/text v0.27.0
 )
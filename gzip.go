@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+)
+
+// gzipMagic starts gzip streams. Cache files written before they were
+// compressed don't, and are read as is.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// readCacheFile reads a cache file, decompressing it if needed.
+func readCacheFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read cache file: %w", err)
+	}
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("could not decompress cache file: %w", err)
+	}
+	defer reader.Close()
+	data, err = io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("could not decompress cache file: %w", err)
+	}
+	return data, nil
+}
+
+// writeCacheFile writes a compressed cache file, as the entries of common
+// words weigh tens of KB.
+func writeCacheFile(path string, data []byte) error {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return fmt.Errorf("could not compress cache file: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("could not compress cache file: %w", err)
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
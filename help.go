@@ -0,0 +1,321 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// commandHelp documents a REPL command for .help.
+type commandHelp struct {
+	Name string
+	// Usages are pairs of usage line and description.
+	Usages     [][2]string
+	Details    string
+	Examples   []string
+	ConfigKeys []string
+}
+
+// helpTopics documents the REPL commands, in the order .help lists them.
+var helpTopics = []commandHelp{
+	{
+		Name:     "help",
+		Usages:   [][2]string{{".help", "Show this help message"}, {".help <command>", "Show the detailed help of a command"}},
+		Examples: []string{".help dict"},
+	},
+	{
+		Name:   "quit",
+		Usages: [][2]string{{".quit", "Exit the program"}},
+	},
+	{
+		Name:       "dict",
+		Usages:     [][2]string{{".dict", "List available dictionaries"}, {".dict <key>", "Set the current dictionary"}},
+		Details:    "The dictionaries depend on the provider. Once one is selected, any input which isn't a command is looked up in it.",
+		Examples:   []string{".dict deen", ".dict enfr"},
+		ConfigKeys: []string{"provider", "cache_ttl"},
+	},
+	{
+		Name:     "swap",
+		Usages:   [][2]string{{".swap", "Flip the direction of the lookups in the current dictionary"}, {".swap both", "Look the current dictionary up in both directions again"}},
+		Details:  "The direction is shown in the prompt, like en→de. The first .swap looks deen up from English.",
+		Examples: []string{".swap", ".swap both"},
+	},
+	{
+		Name:       "provider",
+		Usages:     [][2]string{{".provider", "List dictionary providers"}, {".provider <name>", "Set the dictionary provider"}},
+		Details:    "pons needs an API key, wiktionary defines words of several languages in English, local uses offline dictionaries and dict queries a DICT protocol server.",
+		Examples:   []string{".provider wiktionary"},
+		ConfigKeys: []string{"provider", "api_key", "api_keys", "local_dict_dir", "dict_server"},
+	},
+	{
+		Name:       "examples",
+		Usages:     [][2]string{{".examples", "Show only the example phrases of the last entry"}},
+		ConfigKeys: []string{"show_examples"},
+	},
+	{
+		Name:     "last",
+		Usages:   [][2]string{{".last [<format>]", "Show the last entry again, in another format if given"}},
+		Details:  "The formats are " + strings.Join(outputFormats, ", ") + ".",
+		Examples: []string{".last", ".last oneline"},
+	},
+	{
+		Name:     "as",
+		Usages:   [][2]string{{".as json|md|plain", "Show the last entry again in JSON, Markdown or plain text"}},
+		Examples: []string{".as md"},
+	},
+	{
+		Name:       "again",
+		Usages:     [][2]string{{".again", "Look the last entry up again, bypassing the cache"}},
+		ConfigKeys: []string{"cache_ttl"},
+	},
+	{
+		Name:       "show",
+		Usages:     [][2]string{{".show <n>|all", "Expand a word class section of the last entry"}, {".show #<n>", "Show only the entry numbered #<n>, with all its examples"}},
+		Examples:   []string{".show 2", ".show all", ".show #3"},
+		ConfigKeys: []string{"collapse_sections"},
+	},
+	{
+		Name:       "case",
+		Usages:     [][2]string{{".case typed|lower|auto <word>", "Look a word up sent as typed, lowercased, or retried capitalized if it misses"}},
+		Examples:   []string{".case auto haus", ".case lower HAUS"},
+		ConfigKeys: []string{"query_case"},
+	},
+	{
+		Name:     "correct",
+		Usages:   [][2]string{{".correct", "List your corrections"}, {".correct <n> <translation>", "Save your own translation of entry #<n> of the last result"}, {".correct <n>", "Remove the correction of entry #<n>"}},
+		Details:  "Corrections are shown under the entries of their headword in later lookups. Entries are numbered like .show #<n>, the only entry of a result being 1.",
+		Examples: []string{`.correct 2 "home, building"`, ".correct 2"},
+	},
+	{
+		Name:     "conjugate",
+		Usages:   [][2]string{{".conjugate [<n>]", "Show the conjugation or declension tables of entry #<n> of the last result"}},
+		Details:  "The tables are taken from the English Wiktionary, as PONS doesn't have them, and cached like translations. The first entry is used when no number is given.",
+		Examples: []string{".conjugate", ".conjugate 2"},
+	},
+	{
+		Name:     "syn",
+		Usages:   [][2]string{{".syn <word>", "Show the synonyms of a word in the language queries are looked up from"}, {".syn", "Show the synonyms of the first entry of the last result"}},
+		Details:  "Synonyms are taken from OpenThesaurus for German and Datamuse for English, or the sources set in the [thesaurus] section of the config file, and cached like translations.",
+		Examples: []string{".syn Haus", ".syn"},
+	},
+	{
+		Name:       "digest",
+		Usages:     [][2]string{{".digest [today|week] [<file>]", "Write a Markdown digest of the words looked up today or this week"}},
+		Details:    "The digest lists the words found with their first translations, grouped by dictionary. It's appended to the file given or digest_file, or printed when neither is set. pons-cli --digest today does the same from cron.",
+		Examples:   []string{".digest", ".digest week notes/journal.md"},
+		ConfigKeys: []string{"digest_file"},
+	},
+	{
+		Name:     "session",
+		Usages:   [][2]string{{".session", "List the saved sessions"}, {".session save <name>", "Save the dictionary, the output settings, the filters and the last results"}, {".session load <name>", "Resume a saved session, showing its last results again"}, {".session delete <name>", "Delete a saved session"}},
+		Details:  "Sessions keep the direction picked with .swap, the output format, show_examples, exact, sort and query_case, and the last 10 results. Loading a session changes them until pons-cli exits, without writing the config file.",
+		Examples: []string{".session save verbs", ".session load verbs"},
+	},
+	{
+		Name:     "import",
+		Usages:   [][2]string{{".import list <file|url> [<deck>]", "Look up the words of a list in the current dictionary and save them to a deck"}},
+		Details:  "Lists are CSV files, whose columns are the word, a note shown on the back of its card in .review and tags separated by spaces, commas or semicolons, or plain text with a word or phrase per line; lines starting with # are skipped. Each word found is saved as .save would, to the deck named after the file unless one is given. Words already saved in the dictionary, whatever their case, aren't looked up again nor saved twice: their note and tags are merged into their card, and a summary tells how many were new, merged, already saved or not found.",
+		Examples: []string{".import list chapter3.txt", ".import list https://example.com/week1.csv week1"},
+	},
+	{
+		Name:     "deck",
+		Usages:   [][2]string{{".deck [list]", "List the decks with their cards and due cards"}, {".deck create <name>", "Create a deck"}, {".deck rename <name> <new name>", "Rename a deck, in the default decks and deck rules too"}, {".deck delete <name>", "Delete a deck, its cards going to the default deck"}, {".deck move <word> <deck>", "Move a word saved in the current dictionary to a deck"}},
+		Details:  "Saved words go to the default deck, named default, unless the [default_decks] section of the configuration file sets the deck of their dictionary, e.g. deen = \"German\". .import list saves to the deck given, or else to the deck of the list. Before the deck of the list and the default deck, the [[deck_rules]] tables assign the words matching their dict, tag or word_class to their deck, the first matching rule winning. Review a deck with .review --deck <name>.",
+		Examples: []string{".deck create Goethe", ".deck move Haus Goethe", ".deck rename Goethe Faust"},
+	},
+	{
+		Name:    "raw",
+		Usages:  [][2]string{{".raw", "Print the JSON response of the last lookup"}},
+		Details: "The response is indented and highlighted, HTML snippets included, to debug how entries are rendered. Results of other providers than PONS are encoded again.",
+	},
+	{
+		Name:     "grep",
+		Usages:   [][2]string{{".grep <pattern>", "Show only the rows of the last entry matching a pattern"}},
+		Details:  "The pattern is a case-insensitive regular expression, or plain text if it isn't a valid one. Sources and targets are matched.",
+		Examples: []string{".grep bank", ".grep ^to "},
+	},
+	{
+		Name:       "clear",
+		Usages:     [][2]string{{".clear [header]", "Clear the screen, and print the startup header again"}},
+		Examples:   []string{".clear", ".clear header"},
+		ConfigKeys: []string{"clear_between_results"},
+	},
+	{
+		Name:       "legend",
+		Usages:     [][2]string{{".legend", "Explain the abbreviations used in entries"}},
+		ConfigKeys: []string{"expand_abbrev"},
+	},
+	{
+		Name:       "history",
+		Usages:     [][2]string{{".history", "Show search history by day, with the number of each search"}},
+		ConfigKeys: []string{"search_history_limit", "date_format", "timezone"},
+	},
+	{
+		Name:     "redo",
+		Usages:   [][2]string{{".redo [<n>]", "Look search <n> of .history up again, the last one by default"}, {"!<n>, !!", "Shorthands of .redo <n> and .redo"}},
+		Examples: []string{".redo 3", "!3", "!!"},
+	},
+	{
+		Name:       "queue",
+		Usages:     [][2]string{{".queue", "List the lookups queued while offline"}, {".queue flush", "Fetch and cache the queued lookups"}, {".queue clear", "Empty the queue"}},
+		Details:    "Lookups which fail because the network is unreachable are queued. Once fetched, they're in the cache and the search history.",
+		ConfigKeys: []string{"queue_auto_flush"},
+	},
+	{
+		Name:     "notfound",
+		Usages:   [][2]string{{".notfound [<dict>]", "List the lookups which found nothing"}, {".notfound retry [<dict>]", "Look them up again"}, {".notfound clear [<dict>]", "Remove them from the search history"}},
+		Details:  "A lookup is listed until it succeeds. Retrying tries the spelling variants and compounds, like regular lookups, and forgets the lookups which succeed.",
+		Examples: []string{".notfound", ".notfound retry deen"},
+	},
+	{
+		Name:       "goal",
+		Usages:     [][2]string{{".goal", "Show the progress toward the daily goal"}, {".goal <n>", "Set the daily goal"}},
+		Details:    "The daily goal is a number of lookups and flashcards reviews. The streak counts the consecutive days it was reached.",
+		Examples:   []string{".goal 20"},
+		ConfigKeys: []string{"daily_goal"},
+	},
+	{
+		Name:       "stats",
+		Usages:     [][2]string{{".stats", "Show the streak, the API usage and a calendar of the activity"}, {".stats network", "Show the response times and the cache hit rate of the APIs"}, {".stats reviews", "Show the retention rate and the average ease of each dictionary and deck, and the cards due in the next days"}},
+		ConfigKeys: []string{"daily_goal", "monthly_request_budget"},
+	},
+	{
+		Name:       "wotd",
+		Usages:     [][2]string{{".wotd", "Show the word of the day"}},
+		Details:    "The word of the day is picked, in the current dictionary, from a list of frequent words of its language or from the words you searched.",
+		ConfigKeys: []string{"wotd_source", "wotd_on_startup"},
+	},
+	{
+		Name:     "export",
+		Usages:   [][2]string{{".export glossary csv|tmx [<file>]", "Export the words searched in the current dictionary as a glossary"}, {".export note <dir>", "Export a Markdown note per saved word, for Obsidian"}, {".export worksheet <file> [--blank source|target] [--deck <name>] [--tag <tag>]", "Export a printable vocabulary sheet of the words saved in the current dictionary, of a deck or tag"}, {".export reviews <file>", "Export the review log of the flashcards as CSV"}},
+		Details:  "The glossary has the first translation of each entry of the words, with their part of speech, an example and, in CSV with phonetics on, their pronunciation, for CAT tools like OmegaT or Trados. The file defaults to glossary-<dict>.csv or .tmx in the current directory. Notes have YAML frontmatter with the languages, tags and date the word was saved, followed by its entry; those already in <dir> are left as they are. Worksheets have a column per language, the file extension picking the format: .md for a two-column Markdown table, .html for a page to print or save as PDF from the browser, .tex for LaTeX; --blank leaves a column empty for students to fill in. The review log has a row per review, with the card, its date, grade (1 again to 4 easy), the interval in days and the ease it led to.",
+		Examples: []string{".export glossary csv", ".export glossary tmx ~/omegat/glossary.tmx", ".export worksheet week1.html --blank target", ".export worksheet chapter3.md --deck Goethe --tag chapter3"},
+	},
+	{
+		Name:       "anki",
+		Usages:     [][2]string{{".anki push [<dict>]", "Add the words searched in a dictionary to Anki"}},
+		Details:    "Anki must be running with the AnkiConnect add-on. Each word gets a Basic note, with its first translations on the back; the notes of words already in the deck are updated instead.",
+		Examples:   []string{".anki push", ".anki push deen"},
+		ConfigKeys: []string{"anki_url", "anki_deck"},
+	},
+	{
+		Name:       "sync",
+		Usages:     [][2]string{{".sync", "Merge the search history with the sync file"}},
+		Details:    "The searches made on other machines are added to the history, and the ones made here to the sync file, which should be in a folder synced between your machines.",
+		ConfigKeys: []string{"sync_file"},
+	},
+	{
+		Name:     "cards",
+		Usages:   [][2]string{{".cards <dict> <origin> [<days>]", "Enter flashcards mode"}},
+		Details:  "The cards are drawn from the words searched in <dict>, in the last <days> days if given. <origin> is the language shown first.",
+		Examples: []string{".cards deen de", ".cards deen en 7"},
+	},
+	{
+		Name:       "profile",
+		Usages:     [][2]string{{".profile", "List configuration profiles"}, {".profile <name>", "Switch to a configuration profile"}},
+		Details:    "Each profile has its own settings, such as the API key and the default dictionary, and its own cache. A profile is created with the default settings when first switched to. The profile of the config.toml file is called default.",
+		Examples:   []string{".profile work", ".profile default"},
+		ConfigKeys: []string{"default_dict"},
+	},
+	{
+		Name:       "save",
+		Usages:     [][2]string{{".save", "Save the last entry as a flashcard"}},
+		Details:    "The card shows the word on the front and its first translations on the back. With cloze_cards on, the example phrases of the word are saved as cloze cards too, the word being blanked out.",
+		ConfigKeys: []string{"cloze_cards"},
+	},
+	{
+		Name:       "review",
+		Usages:     [][2]string{{".review [<dict>]", "Review the flashcards due, of a dictionary if given"}, {".review [<dict>] --deck <name>", "Review the flashcards due of a deck"}, {".review [<dict>] [--new <n>] [--due <n>] [--time <duration>]", "Review at most n new cards, n cards reviewed before, or for a while"}},
+		Details:    "After seeing the answer, grade how well you remembered it with a single key, 1 or a (again), 2 or h (hard), 3 or g (good), 4 or e (easy): the card comes back sooner or later accordingly, following the SM-2 algorithm or, with srs_algorithm set to leitner, the Leitner system. The cards shown a day are capped by new_cards_per_day and reviews_per_day, which --new and --due lower for a session; --time ends the session once the duration is over. Pressing u before the next card, or once the last one is graded, undoes the last grade: the card gets its scheduling back and is shown again.",
+		Examples:   []string{".review", ".review deen", ".review --deck verbs", ".review --new 10 --due 50 --time 15m"},
+		ConfigKeys: []string{"srs_algorithm", "new_cards_per_day", "reviews_per_day"},
+	},
+	{
+		Name:     "practice",
+		Usages:   [][2]string{{".practice [<n>]", "Practice typing the translation of saved words"}, {".practice stats", "Show the accuracy of the practiced words"}},
+		Details:  "The words are picked among the saved ones, of the current dictionary if one is selected. Near-misses, such as a missing accent or umlaut, are highlighted.",
+		Examples: []string{".practice", ".practice 20"},
+	},
+	{
+		Name:     "source",
+		Usages:   [][2]string{{".source <file>", "Run the commands of a file"}},
+		Details:  "Commands are read one per line, lines starting with # being comments. The rc file of the config directory is run the same way at startup.",
+		Examples: []string{".source ~/words.txt"},
+	},
+	{
+		Name:       "translate",
+		Usages:     [][2]string{{".translate <sentence>", "Translate a sentence with the machine translation provider"}},
+		Examples:   []string{".translate Wie spät ist es?"},
+		ConfigKeys: []string{"mt_provider", "mt_api_key", "mt_url"},
+	},
+	{
+		Name:     "set",
+		Usages:   [][2]string{{".set", "Show current settings"}, {".set <var> <value>", "Set a configuration variable"}, {".set --reset", "Restore the default settings"}},
+		Details:  "Boolean variables accept on/off, true/false or 1/0. Restoring the default settings keeps the API keys (api_key, api_keys and mt_api_key).",
+		Examples: []string{".set show_examples off", ".set cache_ttl 3600"},
+	},
+	{
+		Name:     "unset",
+		Usages:   [][2]string{{".unset <var>", "Revert a configuration variable to its default"}},
+		Examples: []string{".unset rtl_mode"},
+	},
+}
+
+// getConfigKeys returns the names of the configuration variables.
+func getConfigKeys() []string {
+	var keys []string
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		keys = append(keys, t.Field(i).Tag.Get("toml"))
+	}
+	return keys
+}
+
+func handleHelpCommand(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: .help [<command>]")
+	}
+
+	if len(args) == 0 {
+		color.New(color.FgYellow).Println("Available commands:")
+		for _, topic := range helpTopics {
+			for _, usage := range topic.Usages {
+				fmt.Printf("%s - %s\n", usage[0], usage[1])
+			}
+		}
+		return nil
+	}
+
+	name := strings.TrimPrefix(args[0], ".")
+	for _, topic := range helpTopics {
+		if topic.Name != name {
+			continue
+		}
+		for _, usage := range topic.Usages {
+			color.New(color.FgGreen).Print(usage[0])
+			fmt.Printf(" - %s\n", usage[1])
+		}
+		if topic.Details != "" {
+			fmt.Println()
+			fmt.Println(topic.Details)
+		}
+		if len(topic.Examples) > 0 {
+			color.New(color.FgYellow).Println("\nExamples:")
+			for _, example := range topic.Examples {
+				fmt.Println("  " + example)
+			}
+		}
+		configKeys := topic.ConfigKeys
+		if name == "set" {
+			configKeys = getConfigKeys()
+		}
+		if len(configKeys) > 0 {
+			color.New(color.FgYellow).Println("\nConfiguration variables:")
+			fmt.Println("  " + strings.Join(configKeys, ", "))
+		}
+		return nil
+	}
+	return fmt.Errorf("no help for %s", args[0])
+}
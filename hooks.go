@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hookTimeout is how long a hook may run before it's given up.
+const hookTimeout = 10 * time.Second
+
+// quotaWarningPercent is the share of monthly_request_budget past which the
+// quota_threshold event is sent.
+const quotaWarningPercent = 80
+
+// Events sent to the hooks
+const (
+	eventQuotaThreshold = "quota_threshold"
+	eventReviewDue      = "review_due"
+	eventDailyGoal      = "daily_goal"
+)
+
+// hooksRunning counts the hooks running, which the process waits for
+// before exiting.
+var hooksRunning sync.WaitGroup
+
+// runHooks sends an event to hook_command and hook_url, e.g. to show a
+// desktop notification, in the background so that slow hooks don't hold
+// the command up. Hooks failing are logged only.
+func runHooks(event, message string) {
+	command, hookURL := config.HookCommand, config.HookURL
+	if command == "" && hookURL == "" {
+		return
+	}
+	hooksRunning.Add(1)
+	go func() {
+		defer hooksRunning.Done()
+		if command != "" {
+			if err := runHookCommand(command, event, message); err != nil {
+				// Log the error, but don't fail the command
+				slog.Warn("could not run hook command", "event", event, "err", err)
+			}
+		}
+		if hookURL != "" {
+			if err := postHook(hookURL, event, message); err != nil {
+				// Log the error, but don't fail the command
+				slog.Warn("could not call hook URL", "event", event, "err", err)
+			}
+		}
+	}()
+}
+
+// waitHooks waits for the hooks running to finish, each within
+// hookTimeout.
+func waitHooks() {
+	hooksRunning.Wait()
+}
+
+// runHookCommand runs a hook command in the shell, with the event in
+// PONS_EVENT and its message in PONS_MESSAGE.
+func runHookCommand(command, event, message string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), "PONS_EVENT="+event, "PONS_MESSAGE="+message)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if output := strings.TrimSpace(string(output)); output != "" {
+			return fmt.Errorf("%w: %s", err, output)
+		}
+		return err
+	}
+	return nil
+}
+
+// postHook posts the message of an event to a hook URL as text, which ntfy
+// topics show as is, the event being in the X-Pons-Event header.
+func postHook(hookURL, event, message string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", hookURL, strings.NewReader(message))
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("Title", "pons-cli")
+	req.Header.Set("X-Pons-Event", event)
+
+	resp, err := getHTTPClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+// checkQuotaThreshold sends quota_threshold when the API requests of the
+// month reach quotaWarningPercent of monthly_request_budget, then when
+// they reach the budget.
+func checkQuotaThreshold(requests int) {
+	budget := config.MonthlyRequestBudget
+	if budget == 0 {
+		return
+	}
+	// With small budgets, the threshold is the budget itself
+	switch requests {
+	case budget:
+		runHooks(eventQuotaThreshold, fmt.Sprintf("Monthly request budget of %d reached", budget))
+	case (budget*quotaWarningPercent + 99) / 100:
+		runHooks(eventQuotaThreshold, fmt.Sprintf("%d%% of the monthly request budget used (%d/%d)", quotaWarningPercent, requests, budget))
+	}
+}
+
+// checkReviewDue sends review_due when flashcards are due, at most once a
+// day.
+func checkReviewDue() error {
+	if config.HookCommand == "" && config.HookURL == "" {
+		return nil
+	}
+	_, due, err := countFlashcards(time.Now())
+	if err != nil || due == 0 {
+		return err
+	}
+
+	stateFile, err := getStateFile("review_due_sent")
+	if err != nil {
+		return err
+	}
+	today := time.Now().Format("2006-01-02")
+	if sent, err := os.ReadFile(stateFile); err == nil && strings.TrimSpace(string(sent)) == today {
+		return nil
+	}
+	runHooks(eventReviewDue, fmt.Sprintf("%d flashcards are due for review", due))
+	if err := os.MkdirAll(getAppStateDir(), 0755); err != nil {
+		return fmt.Errorf("could not create state directory: %w", err)
+	}
+	return os.WriteFile(stateFile, []byte(today+"\n"), 0644)
+}
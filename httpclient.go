@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// httpSettings are the config variables the HTTP client is built from.
+type httpSettings struct {
+	connectTimeout int
+	timeout        int
+	proxyURL       string
+}
+
+var (
+	httpClientMu       sync.Mutex
+	httpClient         *http.Client
+	httpClientSettings httpSettings
+)
+
+// checkProxyURL validates the proxy_url config variable, empty meaning the
+// proxy of the environment.
+func checkProxyURL(value string) error {
+	if value == "" {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains([]string{"http", "https", "socks5"}, u.Scheme) || u.Host == "" {
+		return fmt.Errorf("%s is not an http, https or socks5 URL", value)
+	}
+	return nil
+}
+
+// getHTTPClient returns the client of the API requests, rebuilt when its
+// settings change. The client is shared, so that connections are reused.
+func getHTTPClient() *http.Client {
+	settings := httpSettings{
+		connectTimeout: config.ConnectTimeout,
+		timeout:        config.HTTPTimeout,
+		proxyURL:       config.ProxyURL,
+	}
+
+	httpClientMu.Lock()
+	defer httpClientMu.Unlock()
+	if httpClient != nil && settings == httpClientSettings {
+		return httpClient
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   time.Duration(settings.connectTimeout) * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = time.Duration(settings.connectTimeout) * time.Second
+	if proxyURL, err := url.Parse(settings.proxyURL); settings.proxyURL != "" && err == nil {
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if httpClient != nil {
+		httpClient.CloseIdleConnections()
+	}
+	httpClient = &http.Client{
+		Transport: instrumentedTransport{wrapFixtureTransport(transport)},
+		Timeout:   time.Duration(settings.timeout) * time.Second,
+	}
+	httpClientSettings = settings
+	return httpClient
+}
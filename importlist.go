@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/fatih/color"
+)
+
+// maxWordListSize bounds the word lists downloaded by .import list.
+const maxWordListSize = 1 << 20
+
+func handleImportCommand(args []string) error {
+	if len(args) < 2 || len(args) > 3 || args[0] != "list" {
+		return fmt.Errorf("usage: .import list <file|url> [<deck>]")
+	}
+	if currentDict == "" {
+		return fmt.Errorf("no dictionary selected. Use .dict <key> to select one")
+	}
+
+	// The default deck may be named, and a list named default.csv goes to it
+	deck, byRules := getDeck(getListName(args[1])), true
+	if len(args) == 3 {
+		deck, byRules = getDeck(args[2]), false
+	}
+	return importWordList(args[1], currentDict, deck, byRules)
+}
+
+// getListFileName returns the file name of a word list, be it a file or a
+// URL.
+func getListFileName(source string) string {
+	if u, err := url.Parse(source); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return path.Base(u.Path)
+	}
+	return filepath.Base(source)
+}
+
+// getListName returns the name of a word list, its file name without the
+// extension, which names its deck by default.
+func getListName(source string) string {
+	name := getListFileName(source)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if name == "" || name == "." || name == "/" {
+		return "imported"
+	}
+	return name
+}
+
+// readWordList reads a word list from a file or a URL.
+func readWordList(source string) ([]byte, error) {
+	u, err := url.Parse(source)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		content, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("could not read word list: %w", err)
+		}
+		return content, nil
+	}
+
+	resp, err := getHTTPClient().Get(source)
+	if err != nil {
+		return nil, fmt.Errorf("could not download word list: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxWordListSize))
+	if err != nil {
+		return nil, fmt.Errorf("could not read response body: %w", err)
+	}
+	return content, nil
+}
+
+// listEntry is a word of a word list, with the note and tags given along
+// with it in CSV lists.
+type listEntry struct {
+	Word string
+	Note string
+	Tags []string
+}
+
+// parseWordList returns the words of a list: the columns word, note and
+// tags of a CSV file, the tags separated by spaces, commas or semicolons,
+// or a word or phrase per line of a plain list. Blank lines and lines
+// starting with # are skipped, and the words listed twice are merged.
+func parseWordList(content []byte, isCSV bool) ([]listEntry, error) {
+	var entries []listEntry
+	seen := map[string]int{}
+	add := func(record []string) {
+		word := strings.TrimSpace(strings.TrimPrefix(record[0], "\ufeff"))
+		if word == "" || strings.HasPrefix(word, "#") {
+			return
+		}
+		entry := listEntry{Word: word}
+		if len(record) > 1 {
+			entry.Note = strings.TrimSpace(record[1])
+		}
+		if len(record) > 2 {
+			entry.Tags = strings.FieldsFunc(record[2], func(r rune) bool {
+				return r == ',' || r == ';' || unicode.IsSpace(r)
+			})
+		}
+
+		i, ok := seen[strings.ToLower(word)]
+		if !ok {
+			seen[strings.ToLower(word)] = len(entries)
+			entries = append(entries, entry)
+			return
+		}
+		if entries[i].Note == "" {
+			entries[i].Note = entry.Note
+		} else if entry.Note != "" && entry.Note != entries[i].Note {
+			entries[i].Note += "\n" + entry.Note
+		}
+		for _, tag := range entry.Tags {
+			if !slices.Contains(entries[i].Tags, tag) {
+				entries[i].Tags = append(entries[i].Tags, tag)
+			}
+		}
+	}
+
+	if !isCSV {
+		for _, line := range strings.Split(string(content), "\n") {
+			add([]string{line})
+		}
+		return entries, nil
+	}
+
+	reader := csv.NewReader(strings.NewReader(string(content)))
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("could not parse word list: %w", err)
+	}
+	for _, record := range records {
+		add(record)
+	}
+	return entries, nil
+}
+
+// importWordList looks up the words of a list in dict, and saves those
+// found to deck, as .save would. With byRules, the words matching a deck
+// rule go to its deck instead. The words saved already aren't looked up
+// again: the note and tags of the list are merged into their card instead.
+func importWordList(source, dict, deck string, byRules bool) error {
+	content, err := readWordList(source)
+	if err != nil {
+		return err
+	}
+	isCSV := strings.EqualFold(filepath.Ext(getListFileName(source)), ".csv")
+	entries, err := parseWordList(content, isCSV)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no words in %s", source)
+	}
+
+	if deck == "" {
+		// The default deck of dict, like the words saved without a deck
+		deck = getDefaultDeck(dict)
+	}
+
+	saved, ruled, merged, known, failed := 0, 0, 0, 0, 0
+	for i, entry := range entries {
+		progress := fmt.Sprintf("[%d/%d] %s", i+1, len(entries), entry.Word)
+		card, found, err := findWordCard(entry.Word, dict)
+		if err != nil {
+			return err
+		}
+		if found {
+			fmt.Print(progress)
+			changed, err := mergeWordCard(card, entry.Note, entry.Tags)
+			if err != nil {
+				fmt.Println()
+				return err
+			}
+			if changed {
+				color.New(color.FgYellow).Println(" already saved, note and tags merged")
+				merged++
+			} else {
+				color.New(color.FgYellow).Println(" already saved")
+				known++
+			}
+			continue
+		}
+
+		translations, err := getTranslation(entry.Word, dict)
+		// Printed once looked up, since the spinner would erase it
+		fmt.Print(progress)
+		if err != nil {
+			recordFailedLookup(entry.Word, dict, err)
+			color.New(color.FgRed).Printf(" %v\n", err)
+			failed++
+			continue
+		}
+		card = flashcard{Word: entry.Word, Dict: dict, Deck: deck, Note: entry.Note, Tags: strings.Join(entry.Tags, " ")}
+		if byRules {
+			if ruleDeck, ok := matchDeckRule(card, translations); ok {
+				card.Deck = ruleDeck
+				fmt.Printf(" (%s deck)", getDeckName(ruleDeck))
+				ruled++
+			}
+		}
+		if _, err := saveWordCards(card, translations); err != nil {
+			fmt.Println()
+			return err
+		}
+		saved++
+		fmt.Println()
+	}
+
+	color.New(color.FgGreen).Printf("%d words saved to the %s deck", saved-ruled, getDeckName(deck))
+	if ruled > 0 {
+		fmt.Printf(", %d to the decks of deck rules", ruled)
+	}
+	if merged > 0 {
+		fmt.Printf(", %d merged with saved words", merged)
+	}
+	if known > 0 {
+		fmt.Printf(", %d already saved", known)
+	}
+	if failed > 0 {
+		fmt.Printf(", %d not found", failed)
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/chzyer/readline"
+)
+
+// reservedKeys are the keys which can't be bound, as the terminal sends
+// them for Tab and Enter, or readline needs them to interrupt and quit.
+var reservedKeys = []string{"ctrl+c", "ctrl+d", "ctrl+i", "ctrl+j", "ctrl+m"}
+
+// atReplPrompt is whether the line being edited is typed at the REPL
+// prompt, rather than answers asked by commands, which keybindings and
+// suggestions don't apply to.
+var atReplPrompt atomic.Bool
+
+// pendingKeybinding is the command of the last bound key pressed, to be run
+// by the REPL once readline returns the line being edited.
+var pendingKeybinding string
+
+// parseKey returns the rune the terminal sends for a key like "ctrl+o".
+func parseKey(key string) (rune, error) {
+	key = strings.ToLower(key)
+	letter, ok := strings.CutPrefix(key, "ctrl+")
+	if !ok || len(letter) != 1 || letter[0] < 'a' || letter[0] > 'z' {
+		return 0, fmt.Errorf("unsupported key %s, expected ctrl+<letter>", key)
+	}
+	if slices.Contains(reservedKeys, key) {
+		return 0, fmt.Errorf("key %s can't be bound", key)
+	}
+	return rune(letter[0]-'a') + 1, nil
+}
+
+// validateKeybindings checks the keys and the commands of the keybindings.
+func validateKeybindings(keybindings map[string]string) error {
+	for key, command := range keybindings {
+		if _, err := parseKey(key); err != nil {
+			return err
+		}
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("empty command bound to %s", key)
+		}
+	}
+	return nil
+}
+
+// getKeybinding returns the command bound to the key sending r.
+func getKeybinding(r rune) (string, bool) {
+	for key, command := range config.Keybindings {
+		if k, err := parseKey(key); err == nil && k == r {
+			return command, true
+		}
+	}
+	return "", false
+}
+
+// filterKeybinding is the readline input filter running the bound
+// commands. It makes readline return the line being edited, which the REPL
+// doesn't add to the history, runs the command and edits the line again.
+func filterKeybinding(r rune) (rune, bool) {
+	if !atReplPrompt.Load() {
+		return r, true
+	}
+	command, ok := getKeybinding(r)
+	if !ok {
+		return r, true
+	}
+	pendingKeybinding = command
+	return readline.CharEnter, true
+}
+
+// takeKeybinding returns the command of the bound key which made readline
+// return, if any.
+func takeKeybinding() (string, bool) {
+	command := pendingKeybinding
+	if command == "" {
+		return "", false
+	}
+	pendingKeybinding = ""
+	return command, true
+}
+
+// formatKeybindings lists the keybindings sorted by key.
+func formatKeybindings() string {
+	keys := make([]string, 0, len(config.Keybindings))
+	for key := range config.Keybindings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	bindings := make([]string, len(keys))
+	for i, key := range keys {
+		bindings[i] = fmt.Sprintf("%s=%s", key, config.Keybindings[key])
+	}
+	return strings.Join(bindings, ", ")
+}
+
+// applyEditingMode switches the line editor of the REPL to the configured
+// editing mode.
+func applyEditingMode() {
+	if replReadline != nil {
+		replReadline.SetVimMode(config.EditingMode == "vi")
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+
+	"github.com/chzyer/readline"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/mattn/go-runewidth"
+)
+
+// Below this width, the two columns would be too squeezed to be readable, so
+// rows are stacked instead.
+const narrowTermWidth = 70
+
+type lookupResult struct {
+	Word         string
+	Dict         string
+	Translations TranslationResponse
+}
+
+var lastResult *lookupResult
+var lastResultMu sync.Mutex
+
+// recentResults are the last results, the last one included, which sessions
+// keep.
+var recentResults []lookupResult
+
+// resizeRune is read by readline when the terminal is resized, to make it
+// return the line being edited at the REPL prompt so that the REPL redraws
+// the last result. Commands, prompts included, are never drawn over.
+const resizeRune = '\uE000'
+
+// pendingResize is whether readline returned because of a resize.
+var pendingResize atomic.Bool
+
+// filterResize filters resizeRune out of the input of readline, making it
+// return at the REPL prompt. The resizes reaching other prompts, or with no
+// result to redraw, are dropped.
+func filterResize() (rune, bool) {
+	if !atReplPrompt.Load() || getLastResult() == nil {
+		return resizeRune, false
+	}
+	pendingResize.Store(true)
+	return readline.CharEnter, true
+}
+
+// takeResize tells whether readline returned because of a resize.
+func takeResize() bool {
+	return pendingResize.Swap(false)
+}
+
+func setLastResult(word, dict string, translations TranslationResponse) {
+	lastResultMu.Lock()
+	defer lastResultMu.Unlock()
+	lastResult = &lookupResult{Word: word, Dict: dict, Translations: translations}
+	recentResults = append(recentResults, *lastResult)
+	if len(recentResults) > maxSessionResults {
+		recentResults = recentResults[len(recentResults)-maxSessionResults:]
+	}
+	shownSection.Store(0)
+}
+
+func getLastResult() *lookupResult {
+	lastResultMu.Lock()
+	defer lastResultMu.Unlock()
+	return lastResult
+}
+
+// renderRows renders source/target rows as a two-column table fitting
+// width, or as a stacked "source ⇒ target" list when it is narrow. langs are
+// the languages of the two columns.
+func renderRows(w io.Writer, rows []table.Row, langs [2]string, width int) {
+	rtl := [2]bool{isRTL(langs[0]), isRTL(langs[1])}
+	rows = applyTransliteration(rows, langs)
+	rows = applyRTLMode(rows, rtl)
+
+	if width < narrowTermWidth {
+		for _, row := range rows {
+			fmt.Fprintln(w, wrapCell(fmt.Sprint(row[0]), width))
+			if target := fmt.Sprint(row[1]); target != "" {
+				lines := strings.Split(wrapCell(target, width-4), "\n")
+				fmt.Fprintf(w, "  ⇒ %s\n", lines[0])
+				for _, line := range lines[1:] {
+					fmt.Fprintf(w, "    %s\n", line)
+				}
+			}
+		}
+		return
+	}
+
+	headers := langs
+	var aligns []text.Align
+	if config.RTLMode != "off" && (rtl[0] || rtl[1]) {
+		aligns = []text.Align{text.AlignLeft, text.AlignLeft}
+		for i := range rtl {
+			if rtl[i] {
+				aligns[i] = text.AlignRight
+			}
+		}
+		if rtl[0] && !rtl[1] {
+			// Read from the right, the source column comes first
+			aligns[0], aligns[1] = aligns[1], aligns[0]
+			headers[0], headers[1] = headers[1], headers[0]
+			rows = swapColumns(rows)
+		}
+	}
+
+	t := newTable(w, width, aligns...)
+	if config.TableHeader {
+		t.AppendHeader(table.Row{strings.ToUpper(headers[0]), strings.ToUpper(headers[1])})
+	}
+	for _, row := range rows {
+		t.AppendRow(row)
+	}
+	t.Render()
+}
+
+// redrawLastResult renders the last result again, so it fits the current
+// terminal width.
+func redrawLastResult(w io.Writer) {
+	result := getLastResult()
+	if result == nil {
+		return
+	}
+	renderTranslation(w, result.Translations, result.Dict, result.Word)
+}
+
+// wrapCell wraps s so that no line takes more than width terminal cells.
+// Widths are measured with go-runewidth, so double-width CJK runes count for
+// two cells, and color escape sequences for none. Lines are broken at spaces,
+// or between two wide runes since CJK text has no spaces; words longer than
+// a line are broken wherever needed.
+func wrapCell(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		lines = append(lines, wrapLine(paragraph, width)...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func wrapLine(s string, width int) []string {
+	var lines []string
+	var line, word strings.Builder
+	lineWidth, wordWidth := 0, 0
+
+	flushWord := func() {
+		if lineWidth > 0 && lineWidth+wordWidth > width {
+			lines = append(lines, strings.TrimRight(line.String(), " "))
+			line.Reset()
+			lineWidth = 0
+		}
+		line.WriteString(word.String())
+		lineWidth += wordWidth
+		word.Reset()
+		wordWidth = 0
+	}
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\x1b' {
+			// Copy the whole escape sequence, it takes no room on screen
+			for ; i < len(runes) && runes[i] != 'm'; i++ {
+				word.WriteRune(runes[i])
+			}
+			if i < len(runes) {
+				word.WriteRune(runes[i])
+			}
+			continue
+		}
+
+		rw := runewidth.RuneWidth(r)
+		if unicode.IsSpace(r) {
+			flushWord()
+			if lineWidth > 0 && lineWidth < width {
+				line.WriteRune(' ')
+				lineWidth++
+			}
+			continue
+		}
+		if wordWidth+rw > width {
+			// The word alone doesn't fit on a line
+			flushWord()
+		}
+		word.WriteRune(r)
+		wordWidth += rw
+		if rw > 1 {
+			// Wide runes can be broken after
+			flushWord()
+		}
+	}
+	flushWord()
+
+	return append(lines, strings.TrimRight(line.String(), " "))
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"golang.org/x/net/html"
+)
+
+// Abbreviations commonly found in PONS entries. PONS usually gives their
+// meaning in the title of the <acronym> element wrapping them, this table is
+// the fallback when it doesn't.
+var abbreviations = map[string]string{
+	"adj":    "adjective",
+	"adv":    "adverb",
+	"Am":     "American English",
+	"art":    "article",
+	"AUX":    "auxiliary verb",
+	"Aus":    "Australian English",
+	"Brit":   "British English",
+	"CH":     "Swiss German",
+	"conj":   "conjunction",
+	"dat":    "dative",
+	"etw":    "etwas (something)",
+	"f":      "feminine",
+	"fam":    "familiar",
+	"fig":    "figurative",
+	"form":   "formal",
+	"gen":    "genitive",
+	"hum":    "humorous",
+	"inf":    "informal",
+	"interj": "interjection",
+	"iron":   "ironic",
+	"jdm":    "jemandem (to somebody)",
+	"jdn":    "jemanden (somebody)",
+	"jds":    "jemandes (somebody's)",
+	"liter":  "literary",
+	"m":      "masculine",
+	"n":      "noun",
+	"nt":     "neuter",
+	"old":    "old-fashioned",
+	"pej":    "pejorative",
+	"pl":     "plural",
+	"prep":   "preposition",
+	"pron":   "pronoun",
+	"sb":     "somebody",
+	"sing":   "singular",
+	"sl":     "slang",
+	"sth":    "something",
+	"vi":     "intransitive verb",
+	"vr":     "reflexive verb",
+	"vt":     "transitive verb",
+	"vulg":   "vulgar",
+}
+
+// expandAbbreviation returns the meaning of an <acronym> node.
+func expandAbbreviation(n *html.Node) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Key == "title" && attr.Val != "" {
+			return attr.Val, true
+		}
+	}
+	if n.FirstChild != nil && n.FirstChild.Type == html.TextNode {
+		meaning, ok := abbreviations[strings.TrimSpace(n.FirstChild.Data)]
+		return meaning, ok
+	}
+	return "", false
+}
+
+// collectAbbreviations adds the abbreviations of an HTML snippet, with their
+// meaning, to found.
+func collectAbbreviations(htmlString string, found map[string]string) {
+	doc, err := html.Parse(strings.NewReader(htmlString))
+	if err != nil {
+		return
+	}
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "acronym" && n.FirstChild != nil {
+			if meaning, ok := expandAbbreviation(n); ok {
+				found[strings.TrimSpace(nodeText(n))] = meaning
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(doc)
+}
+
+func handleLegendCommand() error {
+	legend := map[string]string{}
+	for abbreviation, meaning := range abbreviations {
+		legend[abbreviation] = meaning
+	}
+
+	// Add the abbreviations of the last entry, which may be missing above
+	if result := getLastResult(); result != nil {
+		for _, lang := range result.Translations {
+			for _, hit := range lang.Hits {
+				collectAbbreviations(hit.Source, legend)
+				collectAbbreviations(hit.Target, legend)
+				for _, rom := range hit.Roms {
+					collectAbbreviations(rom.Headword, legend)
+					for _, arab := range rom.Arabs {
+						collectAbbreviations(arab.Header, legend)
+						for _, translation := range arab.Translations {
+							collectAbbreviations(translation.Source, legend)
+							collectAbbreviations(translation.Target, legend)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(legend))
+	for abbreviation := range legend {
+		keys = append(keys, abbreviation)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return strings.ToLower(keys[i]) < strings.ToLower(keys[j])
+	})
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Abbreviation", "Meaning"})
+	for _, abbreviation := range keys {
+		t.AppendRow(table.Row{abbreviation, legend[abbreviation]})
+	}
+	t.Render()
+	fmt.Println()
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+const ponsEntryURL = "https://en.pons.com/translate/"
+
+// ponsLanguageNames are the names of the languages in the pons.com URLs.
+var ponsLanguageNames = map[string]string{
+	"ar": "arabic",
+	"bg": "bulgarian",
+	"cs": "czech",
+	"da": "danish",
+	"de": "german",
+	"el": "greek",
+	"en": "english",
+	"es": "spanish",
+	"fr": "french",
+	"hu": "hungarian",
+	"it": "italian",
+	"la": "latin",
+	"nl": "dutch",
+	"no": "norwegian",
+	"pl": "polish",
+	"pt": "portuguese",
+	"ru": "russian",
+	"sl": "slovenian",
+	"sv": "swedish",
+	"tr": "turkish",
+	"zh": "chinese",
+}
+
+// getEntryURL returns the URL of the pons.com entry of headword in dict, or
+// "" if the dictionary isn't a PONS one.
+func getEntryURL(dict, headword string) string {
+	if len(dict) != 4 {
+		return ""
+	}
+	source, ok1 := ponsLanguageNames[dict[:2]]
+	target, ok2 := ponsLanguageNames[dict[2:]]
+	if !ok1 || !ok2 {
+		return ""
+	}
+	// Headwords mark the syllables with middle dots
+	word := strings.ReplaceAll(strings.TrimSpace(plainText(headword)), "·", "")
+	if word == "" {
+		return ""
+	}
+	return ponsEntryURL + source + "-" + target + "/" + url.PathEscape(word)
+}
+
+// virtualTerminal is whether the terminal interprets escape sequences other
+// than colors, which old Windows consoles don't.
+var virtualTerminal = true
+
+// supportsHyperlinks tells whether the terminal is known to support OSC 8
+// hyperlinks. Others may print the escape sequences as is.
+func supportsHyperlinks() bool {
+	if color.NoColor || !virtualTerminal {
+		return false
+	}
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper":
+		return true
+	}
+	if version, err := strconv.Atoi(os.Getenv("VTE_VERSION")); err == nil && version >= 5000 {
+		return true
+	}
+	for _, env := range []string{"KITTY_WINDOW_ID", "WT_SESSION", "KONSOLE_VERSION", "DOMTERM"} {
+		if os.Getenv(env) != "" {
+			return true
+		}
+	}
+	term := os.Getenv("TERM")
+	return strings.Contains(term, "kitty") || strings.HasPrefix(term, "foot") || term == "alacritty"
+}
+
+func useHyperlinks() bool {
+	switch config.Hyperlinks {
+	case "on":
+		return true
+	case "off":
+		return false
+	}
+	return supportsHyperlinks()
+}
+
+// hyperlink makes text a link to target in terminals supporting OSC 8.
+func hyperlink(target, text string) string {
+	if target == "" || !useHyperlinks() {
+		return text
+	}
+	return "\x1b]8;;" + target + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
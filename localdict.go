@@ -0,0 +1,418 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Offline dictionaries, in StarDict (.ifo, .idx, .dict[.dz]) or dictd
+// (.index, .dict[.dz]) format, are loaded from local_dict_dir. Their
+// dictionary key is derived from their file name: "deen", "de-en" and
+// "deu-eng" (as FreeDict names them) all give "deen".
+
+const base64Alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+var iso6393Languages = map[string]string{
+	"ara": "ar", "bul": "bg", "ces": "cs", "dan": "da", "deu": "de", "ell": "el",
+	"eng": "en", "spa": "es", "fin": "fi", "fra": "fr", "hun": "hu", "ita": "it",
+	"jpn": "ja", "lat": "la", "nld": "nl", "nor": "no", "pol": "pl", "por": "pt",
+	"ron": "ro", "rus": "ru", "slk": "sk", "slv": "sl", "swe": "sv", "tur": "tr",
+	"ukr": "uk", "zho": "zh",
+}
+
+type localEntry struct {
+	Offset int64
+	Size   int64
+}
+
+type localDictionary struct {
+	Key      string
+	Label    string
+	Langs    []string
+	dataPath string
+	// Type of the entries data, for StarDict dictionaries without
+	// sametypesequence each entry starts with its own
+	sameType string
+	load     func(d *localDictionary) error
+	once     sync.Once
+	loadErr  error
+	index    map[string][]localEntry
+	data     []byte
+}
+
+// localProvider looks words up in the offline dictionaries.
+type localProvider struct{}
+
+var localDictionaries []*localDictionary
+var localDictionariesOnce sync.Once
+
+func init() {
+	registerProvider("local", localProvider{})
+}
+
+func getLocalDictDir() (string, error) {
+	if config.LocalDictDir != "" {
+		return config.LocalDictDir, nil
+	}
+	return getDataFile("dictionaries")
+}
+
+func (localProvider) Dictionaries() ([]Dictionary, error) {
+	var dictionaries []Dictionary
+	for _, d := range getLocalDictionaries() {
+		dictionaries = append(dictionaries, Dictionary{Key: d.Key, SimpleLabel: d.Label, Languages: d.Langs})
+	}
+	return dictionaries, nil
+}
+
+func (localProvider) Lookup(word, dict string) (TranslationResponse, bool, error) {
+	var arabs []Arab
+	found := false
+	for _, d := range getLocalDictionaries() {
+		if d.Key != dict {
+			continue
+		}
+		found = true
+		definitions, err := d.lookup(word)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(definitions) == 0 {
+			continue
+		}
+		arab := Arab{Header: html.EscapeString(d.Label)}
+		for _, definition := range definitions {
+			arab.Translations = append(arab.Translations, Translation{Source: html.EscapeString(word), Target: definition})
+		}
+		arabs = append(arabs, arab)
+	}
+
+	if !found {
+		return nil, false, fmt.Errorf("unknown dictionary key: %s", dict)
+	}
+	if len(arabs) == 0 {
+		return nil, false, errNoTranslation
+	}
+
+	lang := ""
+	if len(dict) == 4 {
+		lang = dict[:2]
+	}
+	return TranslationResponse{{Lang: lang, Hits: []Hit{{Roms: []Rom{{Headword: word, Arabs: arabs}}}}}}, false, nil
+}
+
+func getLocalDictionaries() []*localDictionary {
+	localDictionariesOnce.Do(func() {
+		dir, err := getLocalDictDir()
+		if err != nil {
+			return
+		}
+		localDictionaries = findLocalDictionaries(dir)
+	})
+	return localDictionaries
+}
+
+// findLocalDictionaries finds the dictionaries in dir and its subdirectories,
+// without loading them yet.
+func findLocalDictionaries(dir string) []*localDictionary {
+	var dictionaries []*localDictionary
+	filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		switch {
+		case strings.HasSuffix(path, ".ifo"):
+			if d, err := newStarDict(strings.TrimSuffix(path, ".ifo")); err == nil {
+				dictionaries = append(dictionaries, d)
+			}
+		case strings.HasSuffix(path, ".index"):
+			if d, err := newDictd(strings.TrimSuffix(path, ".index")); err == nil {
+				dictionaries = append(dictionaries, d)
+			}
+		}
+		return nil
+	})
+	sort.Slice(dictionaries, func(i, j int) bool {
+		return dictionaries[i].Key < dictionaries[j].Key
+	})
+	return dictionaries
+}
+
+func sortDictionaries(dictionaries []Dictionary) {
+	sort.Slice(dictionaries, func(i, j int) bool {
+		return dictionaries[i].Key < dictionaries[j].Key
+	})
+}
+
+// getLocalDictKey derives a dictionary key and its languages from a file
+// name like "deen", "de-en" or "deu-eng".
+func getLocalDictKey(base string) (string, []string) {
+	name := strings.ToLower(filepath.Base(base))
+	parts := strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' })
+	if len(parts) == 1 && len(name) == 4 {
+		parts = []string{name[:2], name[2:]}
+	}
+	if len(parts) == 2 {
+		var langs []string
+		for _, part := range parts {
+			if lang, ok := iso6393Languages[part]; ok {
+				part = lang
+			}
+			if len(part) != 2 {
+				return name, nil
+			}
+			langs = append(langs, part)
+		}
+		return langs[0] + langs[1], langs
+	}
+	return name, nil
+}
+
+func findDataFile(base string) (string, error) {
+	for _, path := range []string{base + ".dict", base + ".dict.dz"} {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no data file for %s", base)
+}
+
+func newStarDict(base string) (*localDictionary, error) {
+	dataPath, err := findDataFile(base)
+	if err != nil {
+		return nil, err
+	}
+	info, err := readIfo(base + ".ifo")
+	if err != nil {
+		return nil, err
+	}
+
+	key, langs := getLocalDictKey(base)
+	label := info["bookname"]
+	if label == "" {
+		label = key
+	}
+	return &localDictionary{
+		Key:      key,
+		Label:    label,
+		Langs:    langs,
+		dataPath: dataPath,
+		sameType: info["sametypesequence"],
+		load: func(d *localDictionary) error {
+			return d.loadStarDictIndex(base+".idx", info["idxoffsetbits"] == "64")
+		},
+	}, nil
+}
+
+func newDictd(base string) (*localDictionary, error) {
+	dataPath, err := findDataFile(base)
+	if err != nil {
+		return nil, err
+	}
+	key, langs := getLocalDictKey(base)
+	return &localDictionary{
+		Key:      key,
+		Label:    filepath.Base(base),
+		Langs:    langs,
+		dataPath: dataPath,
+		sameType: "m",
+		load: func(d *localDictionary) error {
+			return d.loadDictdIndex(base + ".index")
+		},
+	}, nil
+}
+
+func readIfo(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), "="); ok {
+			info[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return info, scanner.Err()
+}
+
+func (d *localDictionary) lookup(word string) ([]string, error) {
+	d.once.Do(func() {
+		d.index = map[string][]localEntry{}
+		if d.loadErr = d.load(d); d.loadErr != nil {
+			return
+		}
+		d.loadErr = d.loadData()
+	})
+	if d.loadErr != nil {
+		return nil, fmt.Errorf("could not load dictionary %s: %w", d.Label, d.loadErr)
+	}
+
+	var definitions []string
+	for _, entry := range d.index[strings.ToLower(word)] {
+		if entry.Offset+entry.Size > int64(len(d.data)) {
+			continue
+		}
+		definitions = append(definitions, d.formatEntry(d.data[entry.Offset:entry.Offset+entry.Size]))
+	}
+	return definitions, nil
+}
+
+// loadData reads the whole data file. Dictzip files are gzip files, so they
+// can simply be decompressed.
+func (d *localDictionary) loadData() error {
+	file, err := os.Open(d.dataPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(d.dataPath, ".dz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	d.data, err = io.ReadAll(r)
+	return err
+}
+
+// loadStarDictIndex reads a StarDict .idx file: a sequence of
+// NUL-terminated words, each followed by the offset and size of its entry.
+func (d *localDictionary) loadStarDictIndex(path string, offset64 bool) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	for len(content) > 0 {
+		end := strings.IndexByte(string(content), 0)
+		if end < 0 {
+			break
+		}
+		word := string(content[:end])
+		content = content[end+1:]
+
+		var entry localEntry
+		if offset64 {
+			if len(content) < 12 {
+				break
+			}
+			entry.Offset = int64(binary.BigEndian.Uint64(content))
+			content = content[8:]
+		} else {
+			if len(content) < 8 {
+				break
+			}
+			entry.Offset = int64(binary.BigEndian.Uint32(content))
+			content = content[4:]
+		}
+		entry.Size = int64(binary.BigEndian.Uint32(content))
+		content = content[4:]
+
+		key := strings.ToLower(word)
+		d.index[key] = append(d.index[key], entry)
+	}
+	return nil
+}
+
+// loadDictdIndex reads a dictd .index file, whose lines are a word, then
+// the offset and size of its entry in base64.
+func (d *localDictionary) loadDictdIndex(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "00-database") {
+			continue
+		}
+		offset, err := decodeDictdNumber(fields[1])
+		if err != nil {
+			continue
+		}
+		size, err := decodeDictdNumber(fields[2])
+		if err != nil {
+			continue
+		}
+		key := strings.ToLower(fields[0])
+		d.index[key] = append(d.index[key], localEntry{Offset: offset, Size: size})
+	}
+	return scanner.Err()
+}
+
+func decodeDictdNumber(s string) (int64, error) {
+	var n int64
+	for _, c := range s {
+		i := strings.IndexRune(base64Alphabet, c)
+		if i < 0 {
+			return 0, fmt.Errorf("invalid number: %s", s)
+		}
+		n = n*64 + int64(i)
+	}
+	return n, nil
+}
+
+// formatEntry converts the data of an entry into the HTML of a translation.
+func (d *localDictionary) formatEntry(data []byte) string {
+	if d.sameType != "" {
+		return formatEntryPart(d.sameType[0], string(data))
+	}
+
+	// Each part is a type character followed by NUL-terminated data
+	var parts []string
+	for len(data) > 1 {
+		kind := data[0]
+		data = data[1:]
+		end := strings.IndexByte(string(data), 0)
+		if end < 0 {
+			end = len(data)
+		}
+		parts = append(parts, formatEntryPart(kind, string(data[:end])))
+		if end < len(data) {
+			end++
+		}
+		data = data[end:]
+	}
+	return strings.Join(parts, "\n")
+}
+
+func formatEntryPart(kind byte, data string) string {
+	data = strings.TrimSpace(data)
+	switch kind {
+	case 'h', 'g', 'x':
+		// HTML, Pango markup and XDXF are all rendered by keeping their text
+		return data
+	}
+	return html.EscapeString(data)
+}
+
+// localFallbackAvailable tells whether an offline dictionary can replace the
+// current provider for dict.
+func localFallbackAvailable(dict string) bool {
+	for _, d := range getLocalDictionaries() {
+		if d.Key == dict {
+			return true
+		}
+	}
+	return false
+}
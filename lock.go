@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// withLock runs fn while holding an advisory lock shared by every pons-cli
+// instance, e.g. a REPL and a one-shot lookup run from a script, so that
+// they don't rewrite the same files at the same time.
+func withLock(fn func() error) error {
+	lockFile := filepath.Join(getAppDataDir(), "pons-cli.lock")
+	file, err := os.OpenFile(lockFile, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open lock file: %w", err)
+	}
+	defer file.Close()
+
+	if err := lockFileHandle(file); err != nil {
+		return fmt.Errorf("could not lock %s: %w", lockFile, err)
+	}
+	defer func() {
+		if err := unlockFileHandle(file); err != nil {
+			slog.Warn("could not unlock", "file", lockFile, "err", err)
+		}
+	}()
+
+	return fn()
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	logFileName = "pons-cli.log"
+	// maxLogSize is the size of the log file from which it's rotated.
+	maxLogSize = 1 << 20
+	// maxLogFiles is the number of rotated log files kept.
+	maxLogFiles = 3
+)
+
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+func parseLogLevel(value string) (slog.Level, error) {
+	level, ok := logLevels[strings.ToLower(value)]
+	if !ok {
+		return 0, fmt.Errorf("%s is not one of debug, info, warn, error", value)
+	}
+	return level, nil
+}
+
+// configLevel is the log level of the config, which may change while
+// running.
+type configLevel struct{}
+
+func (configLevel) Level() slog.Level {
+	level, err := parseLogLevel(config.LogLevel)
+	if err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// rotatingFile is a log file which is renamed to .1, .2... once it reaches
+// maxLogSize, the oldest one being removed.
+type rotatingFile struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	size int64
+}
+
+func openRotatingFile(path string) (*rotatingFile, error) {
+	f := &rotatingFile{path: path}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("could not stat log file: %w", err)
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+func (f *rotatingFile) rotate() error {
+	f.file.Close()
+	for i := maxLogFiles - 1; i > 0; i-- {
+		// The files which don't exist yet are skipped
+		os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+	}
+	if err := os.Rename(f.path, f.path+".1"); err != nil {
+		return fmt.Errorf("could not rotate log file: %w", err)
+	}
+	return f.open()
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.size+int64(len(p)) > maxLogSize && f.size > 0 {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// setupLogging sends the logs to pons-cli.log in the state directory, so
+// that background errors don't clutter the output.
+func setupLogging() error {
+	path, err := getStateFile(logFileName)
+	if err != nil {
+		return fmt.Errorf("could not get log file path: %w", err)
+	}
+	file, err := openRotatingFile(path)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(file, &slog.HandlerOptions{Level: configLevel{}})))
+	return nil
+}
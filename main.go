@@ -1,50 +1,125 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"database/sql"
 
 	"github.com/BurntSushi/toml"
-	"github.com/adrg/xdg"
 	"github.com/chzyer/readline"
 	"github.com/eiannone/keyboard"
 	"github.com/fatih/color"
 	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
 	"golang.org/x/net/html"
 	"golang.org/x/term"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+const version = "0.1.0"
+
 const baseURL = "https://api.pons.com/v1/"
 
 const dictionaryURL = baseURL + "dictionary"
 const dictionariesURL = baseURL + "dictionaries"
 
 type Config struct {
-	APIKey             string `toml:"api_key"`
-	CacheTTL           int    `toml:"cache_ttl"`
-	CmdHistoryLimit    int    `toml:"cmd_history_limit"`
-	SearchHistoryLimit int    `toml:"search_history_limit"`
+	APIKey               string `toml:"api_key"`
+	CacheTTL             int    `toml:"cache_ttl"`
+	CmdHistoryLimit      int    `toml:"cmd_history_limit"`
+	SearchHistoryLimit   int    `toml:"search_history_limit"`
+	Transcript           string `toml:"transcript"`
+	RTLMode              string `toml:"rtl_mode"`
+	Transliterate        bool   `toml:"transliterate"`
+	MTProvider           string `toml:"mt_provider"`
+	MTAPIKey             string `toml:"mt_api_key"`
+	MTURL                string `toml:"mt_url"`
+	Provider             string `toml:"provider"`
+	LocalDictDir         string `toml:"local_dict_dir"`
+	DictServer           string `toml:"dict_server"`
+	SpellingVariants     bool   `toml:"spelling_variants"`
+	ShowExamples         bool   `toml:"show_examples"`
+	ExpandAbbrev         bool   `toml:"expand_abbrev"`
+	GenderColors         bool   `toml:"gender_colors"`
+	GenderPalette        string `toml:"gender_palette"`
+	CollapseSections     bool   `toml:"collapse_sections"`
+	DefaultDict          string `toml:"default_dict"`
+	SyncFile             string `toml:"sync_file"`
+	AnkiURL              string `toml:"anki_url"`
+	AnkiDeck             string `toml:"anki_deck"`
+	WOTDSource           string `toml:"wotd_source"`
+	WOTDOnStartup        bool   `toml:"wotd_on_startup"`
+	DailyGoal            int    `toml:"daily_goal"`
+	ClozeCards           bool   `toml:"cloze_cards"`
+	QueueAutoFlush       bool   `toml:"queue_auto_flush"`
+	MonthlyRequestBudget int    `toml:"monthly_request_budget"`
+	ConnectTimeout       int    `toml:"connect_timeout"`
+	HTTPTimeout          int    `toml:"http_timeout"`
+	ProxyURL             string `toml:"proxy_url"`
+	Debug                bool   `toml:"debug"`
+	LogLevel             string `toml:"log_level"`
+	Hyperlinks           string `toml:"hyperlinks"`
+	TableStyle           string `toml:"table_style"`
+	ColumnSeparator      bool   `toml:"column_separator"`
+	RowSeparator         bool   `toml:"row_separator"`
+	CellPadding          int    `toml:"cell_padding"`
+	TableHeader          bool   `toml:"table_header"`
+	Accessible           bool   `toml:"accessible"`
+	EditingMode          string `toml:"editing_mode"`
+
+	Keybindings         map[string]string `toml:"keybindings"`
+	ClearBetweenResults bool              `toml:"clear_between_results"`
+	Autosuggest         bool              `toml:"autosuggest"`
+	DateFormat          string            `toml:"date_format"`
+	Timezone            string            `toml:"timezone"`
+	Exact               bool              `toml:"exact"`
+	QueryCase           string            `toml:"query_case"`
+	NormalizeInput      bool              `toml:"normalize_input"`
+	StripClitics        bool              `toml:"strip_clitics"`
+	ShowOrigin          bool              `toml:"show_origin"`
+	Sort                string            `toml:"sort"`
+	Thesaurus           map[string]string `toml:"thesaurus"`
+	DefaultDecks        map[string]string `toml:"default_decks"`
+	DeckRules           []deckRule        `toml:"deck_rules"`
+	Phonetics           bool              `toml:"phonetics"`
+	FrequencyAnnotation string            `toml:"frequency_annotation"`
+	DigestFile          string            `toml:"digest_file"`
+	EventLog            string            `toml:"event_log"`
+	HookCommand         string            `toml:"hook_command"`
+	HookURL             string            `toml:"hook_url"`
+	APIKeys             string            `toml:"api_keys"`
+	SRSAlgorithm        string            `toml:"srs_algorithm"`
+	NewCardsPerDay      int               `toml:"new_cards_per_day"`
+	ReviewsPerDay       int               `toml:"reviews_per_day"`
+	CompoundLookups     bool              `toml:"compound_lookups"`
 }
 
 var config Config
 var currentDict string
 var db *sql.DB
 
+// bypassCache makes lookups ignore cached results, to refresh them.
+var bypassCache atomic.Bool
+
 // Dictionary represents a single dictionary from the PONS API
 
 type Dictionary struct {
@@ -60,14 +135,21 @@ type TranslationResponse []struct {
 }
 
 type Hit struct {
-	Roms   []Rom  `json:"roms"`
-	Source string `json:"source"`
-	Target string `json:"target"`
+	Type     string `json:"type"`
+	OpenDict bool   `json:"opendict"`
+	Roms     []Rom  `json:"roms"`
+	Source   string `json:"source"`
+	Target   string `json:"target"`
 }
 
 type Rom struct {
-	Headword string `json:"headword"`
-	Arabs    []Arab `json:"arabs"`
+	Headword     string `json:"headword"`
+	HeadwordFull string `json:"headword_full"`
+	WordClass    string `json:"wordclass"`
+	Arabs        []Arab `json:"arabs"`
+
+	// Origin is where the hit of the rom comes from, see getHitOrigin
+	Origin string `json:"-"`
 }
 
 type Arab struct {
@@ -93,56 +175,197 @@ Note: You may need to create an account on the PONS website.
 `
 
 func main() {
+	logSession := flag.Bool("log-session", false, "append every query and result to a timestamped transcript file")
+	dict := flag.String("dict", "", "dictionary to use, e.g. deen")
+	flag.StringVar(&configPath, "config", "", "path of the config file")
+	portable := flag.Bool("portable", false, "keep the config, cache and data in a "+portableDirName+" directory next to the binary")
+	flag.StringVar(&portableDir, "portable-dir", "", "keep the config, cache and data in this directory")
+	flag.StringVar(&currentProfile, "profile", "", "configuration profile to use, with its own settings and cache")
+	exec := flag.String("exec", "", "run REPL commands, separated by \";\", then exit")
+	digest := flag.String("digest", "", "write the Markdown digest of the words looked up today or this week (\"today\" or \"week\") to digest_file, or print it, then exit")
+	oneline := flag.Bool("oneline", false, "print one \"source → target\" pair per line, without colors or tables, like --format oneline")
+	flag.StringVar(&outputFormat, "format", "table", "output format: "+strings.Join(outputFormats, ", ")+"; errors are printed as JSON with the json format")
+	flag.BoolVar(&quietOutput, "quiet", false, "don't print the welcome banner, informational notices nor the progress spinner")
+	flag.BoolVar(&debugFlag, "debug", false, "log the HTTP requests and responses to debug.log in the state directory")
+	flag.StringVar(&recordFixturesDir, "record-fixtures", "", "save the API responses to this directory, bypassing the cache")
+	flag.StringVar(&replayFixturesDir, "replay-fixtures", "", "serve the API responses from this directory, without network")
+	flag.BoolVar(&forceRequests, "force", false, "make API requests even when monthly_request_budget is exceeded")
+	flag.Parse()
+
+	if err := checkProfileName(currentProfile); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(exitError)
+	}
+
+	if *portable && portableDir == "" {
+		dir, err := getDefaultPortableDir()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: could not find the binary directory:", err)
+			os.Exit(exitError)
+		}
+		portableDir = dir
+	} else if portableDir == "" {
+		detectPortableDir()
+	}
+
+	virtualTerminal = enableVirtualTerminal()
+
+	if err := setupFixtures(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(exitError)
+	}
+
+	if *oneline {
+		outputFormat = "oneline"
+	}
+	if !slices.Contains(outputFormats, outputFormat) {
+		fmt.Fprintf(os.Stderr, "Error: unknown format: %s (available: %s)\n", outputFormat, strings.Join(outputFormats, ", "))
+		os.Exit(exitError)
+	}
+
 	if err := setup(); err != nil {
 		fmt.Println("Error setting up config:", err)
 		return
 	}
+	// The hooks run in the background
+	defer waitHooks()
+	applyAccessibleMode()
+
+	currentDict = *dict
+	if currentDict == "" {
+		currentDict = config.DefaultDict
+	}
+
+	switch flag.Arg(0) {
+	case "serve":
+		if err := runServer(flag.Args()[1:]); err != nil {
+			fmt.Println("Error running server:", err)
+		}
+		return
+	case "mcp":
+		if err := runMCPServer(); err != nil {
+			slog.Error("could not run MCP server", "err", err)
+		}
+		return
+	case "extract":
+		if err := runExtract(flag.Args()[1:]); err != nil {
+			fmt.Println("Error:", err)
+		}
+		return
+	case "remind":
+		if err := runRemind(flag.Args()[1:]); err != nil {
+			fmt.Println("Error:", err)
+		}
+		return
+	case "backup":
+		if err := runBackup(flag.Args()[1:]); err != nil {
+			fmt.Println("Error:", err)
+		}
+		return
+	case "restore":
+		if err := runRestore(flag.Args()[1:]); err != nil {
+			fmt.Println("Error:", err)
+		}
+		return
+	}
+
+	spinnerEnabled = term.IsTerminal(int(os.Stderr.Fd())) && !quietOutput && outputFormat != "json"
+
+	if *logSession {
+		if err := startSessionTranscript(); err != nil {
+			fmt.Println("Error creating session transcript:", err)
+			return
+		}
+	}
 
-	if config.APIKey == "" {
-		color.New(color.FgYellow).Print(welcomeMessage)
-		fmt.Println("")
+	if *digest != "" {
+		if err := runDigest(*digest, config.DigestFile); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			exitOnError(err)
+		}
+		return
 	}
 
-	color.New(color.FgYellow).Println("Type .help for more information.")
+	if *exec != "" {
+		runCommand(*exec)
+		exitOnError(lastCommandErr)
+		return
+	}
 
-	historyFile, err := getDataFile("cmd_history.txt")
-	if err != nil {
-		fmt.Println("Error creating history file:", err)
+	// Words given on the command line are looked up without entering the REPL
+	if flag.NArg() > 0 {
+		// Sections couldn't be expanded afterwards
+		config.CollapseSections = false
+		if err := handleTranslation(strings.Join(flag.Args(), " "), getLookupOptions()); err != nil {
+			if outputFormat == "json" {
+				printError(err)
+			} else {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+			}
+			exitOnError(err)
+		}
 		return
 	}
+
+	if !quietOutput {
+		printHeader()
+	}
+
 	rl, err := readline.NewEx(&readline.Config{
 		Prompt:          ">>> ",
-		HistoryFile:     historyFile,
 		HistoryLimit:    config.CmdHistoryLimit,
 		InterruptPrompt: "^C",
 		EOFPrompt:       ".quit",
+		VimMode:         config.EditingMode == "vi",
+		Stdin:           newReplStdin(),
+		Painter:         replSuggester,
+
+		// The REPL saves the commands to the command history itself
+		DisableAutoSaveHistory: true,
+		FuncFilterInputRune:    filterInputRune,
 	})
 	if err != nil {
 		panic(err)
 	}
+	defer rl.Close()
 
-	if err := trimHistoryFile(historyFile, config.CmdHistoryLimit); err != nil {
-		log.Printf("Error trimming history at startup: %v", err)
+	if err := loadCommandHistory(rl); err != nil {
+		slog.Warn("could not load command history", "err", err)
 	}
 
-	defer func() {
-		if err := trimHistoryFile(historyFile, config.CmdHistoryLimit); err != nil {
-			log.Printf("Error trimming history on close: %v", err)
-		}
-		rl.Close()
-	}()
+	replReadline = rl
+	watchResize()
+
+	quit, err := runRCFile()
+	if err != nil {
+		color.New(color.FgRed, color.Bold).Println("Error running rc file:", err)
+	}
+	if quit {
+		return
+	}
 
+	if err := showWordOfTheDayOnStartup(); err != nil {
+		color.New(color.FgRed, color.Bold).Println("Error showing the word of the day:", err)
+	}
+
+	if err := checkReviewDue(); err != nil {
+		// Log the error, but don't fail the REPL
+		slog.Warn("could not check due flashcards", "err", err)
+	}
+
+	editedLine := ""
 	for {
+		prompt := ">>> "
 		if currentDict != "" {
-			color.New(color.FgYellow).Printf("%s >>> ", currentDict)
-			yellow := "\033[33m"
-			reset := "\033[0m"
-			rl.SetPrompt(yellow + currentDict + " >>> " + reset)
-		} else {
-			fmt.Print(">>> ")
-			rl.SetPrompt(">>> ")
+			prompt = color.New(color.FgYellow).Sprintf("%s >>> ", getPromptLabel())
 		}
-		input, err := rl.Readline()
+		fmt.Fprint(color.Output, prompt)
+		rl.SetPrompt(prompt)
+		replSuggester.prepare(prompt)
+		atReplPrompt.Store(true)
+		input, err := rl.ReadlineWithDefault(editedLine)
+		atReplPrompt.Store(false)
+		editedLine = ""
 		if err != nil {
 			// Handle EOF gracefully
 			if err.Error() == "EOF" {
@@ -153,236 +376,562 @@ func main() {
 			return
 		}
 
-		input = strings.TrimSpace(input)
-		parts := strings.Fields(input)
-		if len(parts) == 0 {
+		if takeResize() {
+			// Redraw on the main goroutine, then edit the line again
+			editedLine = input
+			redrawLastResult(color.Output)
 			continue
 		}
-
-		command := parts[0]
-		args := parts[1:]
-
-		switch command {
-		case ".quit":
+		if command, ok := takeKeybinding(); ok {
+			// Edit the line again once the bound command ran
+			editedLine = input
+			input = command
+		} else {
+			saveCommandHistory(rl, input)
+			clearBetweenResults(input)
+		}
+		if quit := runCommand(input); quit {
 			return
-		case ".help":
-			handleHelpCommand()
-		case ".history":
-			if err := handleHistoryCommand(); err != nil {
-				color.New(color.FgRed, color.Bold).Println("Error:", err)
-			}
-		case ".cards":
-			if err := handleCardsCommand(args); err != nil {
-				color.New(color.FgRed, color.Bold).Println("Error:", err)
-			}
-		case ".dict":
-			if err := handleDictCommand(args); err != nil {
-				color.New(color.FgRed, color.Bold).Println("Error:", err)
-			}
-		case ".set":
-			if err := handleSetCommand(args); err != nil {
-				color.New(color.FgRed, color.Bold).Println("Error:", err)
-			}
-		default:
-			if err := handleTranslation(command); err != nil {
-				color.New(color.FgRed, color.Bold).Println("Error:", err)
-			}
 		}
 	}
 }
 
-func trimHistoryFile(filename string, maxLines int) error {
-	// Read the file
-	file, err := os.Open(filename)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil // File doesn't exist, nothing to trim
+// runCommand runs a line of REPL input, made of commands or words to look
+// up separated by ";". It returns true when the line asks to quit.
+func runCommand(input string) bool {
+	for _, command := range splitCommands(input) {
+		if quit := runSingleCommand(command); quit {
+			return true
 		}
-		return err
 	}
-	defer file.Close()
+	return false
+}
 
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+func runSingleCommand(input string) bool {
+	parts, err := tokenize(input)
+	if err != nil {
+		printError(err)
+		return false
 	}
-
-	if err := scanner.Err(); err != nil {
-		return err
+	if len(parts) == 0 {
+		return false
 	}
 
-	// Trim if necessary
-	if len(lines) > maxLines {
-		lines = lines[len(lines)-maxLines:]
-	}
+	command := parts[0]
+	args := parts[1:]
 
-	// Write back
-	return os.WriteFile(filename, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+	switch command {
+	case ".quit":
+		return true
+	case ".help":
+		if err := handleHelpCommand(args); err != nil {
+			printError(err)
+		}
+	case ".history":
+		if err := handleHistoryCommand(); err != nil {
+			printError(err)
+		}
+	case ".queue":
+		if err := handleQueueCommand(args); err != nil {
+			printError(err)
+		}
+	case ".notfound":
+		if err := handleNotFoundCommand(args); err != nil {
+			printError(err)
+		}
+	case ".cards":
+		if err := handleCardsCommand(args); err != nil {
+			printError(err)
+		}
+	case ".dict":
+		if err := handleDictCommand(args); err != nil {
+			printError(err)
+		}
+	case ".set":
+		if err := handleSetCommand(args); err != nil {
+			printError(err)
+		}
+	case ".examples":
+		if err := handleExamplesCommand(); err != nil {
+			printError(err)
+		}
+	case ".grep":
+		if err := handleGrepCommand(args); err != nil {
+			printError(err)
+		}
+	case ".last":
+		if err := handleLastCommand(args); err != nil {
+			printError(err)
+		}
+	case ".case":
+		if err := handleCaseCommand(args); err != nil {
+			printError(err)
+		}
+	case ".swap":
+		if err := handleSwapCommand(args); err != nil {
+			printError(err)
+		}
+	case ".raw":
+		if err := handleRawCommand(); err != nil {
+			printError(err)
+		}
+	case ".correct":
+		if err := handleCorrectCommand(args); err != nil {
+			printError(err)
+		}
+	case ".conjugate":
+		if err := handleConjugateCommand(args); err != nil {
+			printError(err)
+		}
+	case ".syn":
+		if err := handleSynCommand(args); err != nil {
+			printError(err)
+		}
+	case ".digest":
+		if err := handleDigestCommand(args); err != nil {
+			printError(err)
+		}
+	case ".session":
+		if err := handleSessionCommand(args); err != nil {
+			printError(err)
+		}
+	case ".import":
+		if err := handleImportCommand(args); err != nil {
+			printError(err)
+		}
+	case ".deck":
+		if err := handleDeckCommand(args); err != nil {
+			printError(err)
+		}
+	case ".as":
+		if err := handleAsCommand(args); err != nil {
+			printError(err)
+		}
+	case ".again":
+		if err := handleAgainCommand(); err != nil {
+			printError(err)
+		}
+	case ".show":
+		if err := handleShowCommand(args); err != nil {
+			printError(err)
+		}
+	case ".clear":
+		if err := handleClearCommand(args); err != nil {
+			printError(err)
+		}
+	case ".legend":
+		if err := handleLegendCommand(); err != nil {
+			printError(err)
+		}
+	case ".provider":
+		if err := handleProviderCommand(args); err != nil {
+			printError(err)
+		}
+	case ".profile":
+		if err := handleProfileCommand(args); err != nil {
+			printError(err)
+		}
+	case ".unset":
+		if err := handleUnsetCommand(args); err != nil {
+			printError(err)
+		}
+	case ".save":
+		if err := handleSaveCommand(); err != nil {
+			printError(err)
+		}
+	case ".review":
+		if err := handleReviewCommand(args); err != nil {
+			printError(err)
+		}
+	case ".practice":
+		if err := handlePracticeCommand(args); err != nil {
+			printError(err)
+		}
+	case ".goal":
+		if err := handleGoalCommand(args); err != nil {
+			printError(err)
+		}
+	case ".stats":
+		if err := handleStatsCommand(args); err != nil {
+			printError(err)
+		}
+	case ".wotd":
+		if err := handleWOTDCommand(); err != nil {
+			printError(err)
+		}
+	case ".export":
+		if err := handleExportCommand(args); err != nil {
+			printError(err)
+		}
+	case ".anki":
+		if err := handleAnkiCommand(args); err != nil {
+			printError(err)
+		}
+	case ".sync":
+		if err := handleSyncCommand(); err != nil {
+			printError(err)
+		}
+	case ".source":
+		quit, err := handleSourceCommand(args)
+		if err != nil {
+			printError(err)
+		}
+		return quit
+	case ".translate":
+		if err := handleTranslateCommand(args); err != nil {
+			printError(err)
+		}
+	case ".redo":
+		if err := handleRedoCommand(args); err != nil {
+			printError(err)
+		}
+	case "!!":
+		if err := handleRedoCommand(nil); err != nil {
+			printError(err)
+		}
+	default:
+		// !N is a shorthand for .redo N
+		if n, ok := strings.CutPrefix(command, "!"); ok && len(args) == 0 {
+			if err := handleRedoCommand([]string{n}); err != nil {
+				printError(err)
+			}
+			return false
+		}
+		opts := getLookupOptions()
+		// A quoted query only matches entries of that exact term
+		if strings.HasPrefix(strings.TrimSpace(input), `"`) {
+			opts.Exact = true
+		}
+		if err := handleTranslation(command, opts); err != nil {
+			printError(err)
+		}
+	}
+	return false
 }
 
-func handleTranslation(word string) error {
+func handleTranslation(word string, opts lookupOptions) error {
 	if currentDict == "" {
 		return fmt.Errorf("no dictionary selected. Use .dict <key> to select one")
 	}
 
-	translations, err := getTranslation(word, currentDict)
+	return lookupWordWith(normalizeQuery(word, currentDict), currentDict, opts)
+}
+
+// lookupWord looks word up in dict, renders the result and records it.
+func lookupWord(word, dict string) error {
+	return lookupWordWith(word, dict, getLookupOptions())
+}
+
+// lookupWordWith looks word up like lookupWord, with the given options.
+// Exact lookups keep only the entries of word itself, and look neither
+// spelling variants nor compound parts up instead.
+func lookupWordWith(word, dict string, opts lookupOptions) error {
+	word = applyQueryCase(word, opts.Case)
+	translations, cached, err := lookupTranslation(word, dict)
+	if errors.Is(err, errNoTranslation) && opts.Case == "auto" && capitalize(word) != word {
+		// German nouns are only found capitalized
+		if capitalized, capitalizedCached, capitalizedErr := lookupTranslation(capitalize(word), dict); capitalizedErr == nil {
+			word, translations, cached, err = capitalize(word), capitalized, capitalizedCached, nil
+		}
+	}
+	if err == nil && opts.Exact {
+		translations, err = selectExactMatches(translations, word)
+	}
+	if errors.Is(err, errNoTranslation) && config.SpellingVariants && !opts.Exact {
+		if variant, variantTranslations, ok := lookupSpellingVariants(word, dict); ok {
+			printNotice("No entry for %s, showing %s instead\n", word, variant)
+			word, translations, err = variant, variantTranslations, nil
+		}
+	}
 	if err != nil {
-		return err
+		if !errors.Is(err, errNoTranslation) || !config.CompoundLookups || !isGermanDict(dict) || opts.Exact {
+			recordFailedLookup(word, dict, err)
+			return queueLookup(word, dict, err)
+		}
+		// Look the parts of a German compound up instead
+		parts, merged, compoundErr := lookupCompound(word, dict)
+		if compoundErr != nil {
+			recordFailedLookup(word, dict, err)
+			return err
+		}
+		printNotice("No entry for %s, showing its parts: %s\n", word, strings.Join(parts, " + "))
+		translations = merged
+	}
+
+	setLastResult(word, dict, translations)
+
+	var buf bytes.Buffer
+	renderTranslation(&buf, translations, dict, word)
+	fmt.Fprint(color.Output, buf.String())
+
+	if err := appendTranscript(word, dict, buf.String()); err != nil {
+		// Log the error, but don't fail the command
+		slog.Warn("could not write transcript", "err", err)
 	}
 
-	displayTranslation(translations, currentDict)
+	if err := addSearchHistory(word, dict, cached); err != nil {
+		// Log the error, but don't fail the command
+		slog.Warn("could not add search history", "err", err)
+	}
 
-	if err := addSearchHistory(word, currentDict); err != nil {
+	if err := recordActivity(1, 0); err != nil {
 		// Log the error, but don't fail the command
-		log.Printf("could not add search history: %v", err)
+		slog.Warn("could not record activity", "err", err)
+	}
+
+	if config.QueueAutoFlush {
+		autoFlushQueue()
 	}
 
 	return nil
 }
 
+// errNoTranslation is returned by the lookups which found no entry.
+var errNoTranslation = errors.New("no translation found")
+
 func getTranslation(word, dict string) (TranslationResponse, error) {
-	// Caching logic
+	translations, _, err := lookupTranslation(word, dict)
+	return translations, err
+}
+
+// lookupTranslation looks word up like getTranslation, and tells whether
+// the entries were served from the cache.
+func lookupTranslation(word, dict string) (TranslationResponse, bool, error) {
+	provider, err := getProvider()
+	if err != nil {
+		return nil, false, err
+	}
+
+	translations, cached, err := provider.Lookup(word, dict)
+	if err != nil && isUnavailableError(err) && config.Provider != "local" && localFallbackAvailable(dict) {
+		slog.Info("using offline dictionaries", "err", err)
+		translations, cached, err = providers["local"].Lookup(word, dict)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if translations = selectDirection(translations, dict); len(translations) == 0 {
+		return nil, false, errNoTranslation
+	}
+	return translations, cached, nil
+}
+
+// isUnavailableError tells whether err means the provider can't be reached,
+// or refuses requests because the quota or the budget is exceeded.
+func isUnavailableError(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) || getStatusCode(err) == http.StatusTooManyRequests || errors.Is(err, errBudgetExceeded)
+}
+
+// getPONSCacheFile returns the cache file of the PONS response for word in
+// dict.
+func getPONSCacheFile(word, dict string) (string, error) {
 	cacheKey := getTranslationCacheKey(word, dict)
-	cacheFile, err := getCacheFile(cacheKey + ".json")
+	if source := getSourceLang(dict); source != "" {
+		// Lookups in a single direction are cached apart
+		cacheKey = getTranslationCacheKey(word, dict+"_"+source)
+	}
+	return getCacheFile(cacheKey + ".json")
+}
+
+func getPONSTranslation(word, dict string) (TranslationResponse, bool, error) {
+	// Caching logic
+	source := getSourceLang(dict)
+	cacheFile, err := getPONSCacheFile(word, dict)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
-	cacheTTL := time.Duration(config.CacheTTL) * time.Second
-	if isCacheValid(cacheFile, cacheTTL) {
-		file, err := os.Open(cacheFile)
-		if err != nil {
-			return nil, fmt.Errorf("could not open cache file: %w", err)
-		}
-		defer file.Close()
+	overBudget, err := isBudgetExceeded()
+	if err != nil {
+		return nil, false, err
+	}
 
-		body, err := io.ReadAll(file)
-		if err != nil {
-			return nil, fmt.Errorf("could not read cache file: %w", err)
-		}
+	cacheTTL := time.Duration(config.CacheTTL) * time.Second
+	// Over budget, expired entries are better than nothing
+	_, statErr := os.Stat(cacheFile)
+	if isCacheValid(cacheFile, cacheTTL) || (overBudget && statErr == nil) {
+		recordCacheHit(dictionaryURL)
+		translations, err := readTranslationCache(cacheFile)
+		return translations, err == nil, err
+	}
 
-		var translations TranslationResponse
-		if err := json.Unmarshal(body, &translations); err != nil {
-			return nil, fmt.Errorf("could not unmarshal cached json: %w", err)
-		}
-		return translations, nil
+	if overBudget {
+		return nil, false, fmt.Errorf("%w (%d requests), use --force to go over it", errBudgetExceeded, config.MonthlyRequestBudget)
 	}
 
 	req, err := http.NewRequest("GET", dictionaryURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("could not create request: %w", err)
+		return nil, false, fmt.Errorf("could not create request: %w", err)
 	}
 
 	q := req.URL.Query()
 	q.Add("q", word)
 	q.Add("l", dict)
+	if source != "" {
+		q.Add("in", source)
+	}
 	req.URL.RawQuery = q.Encode()
-	req.Header.Add("X-Secret", config.APIKey)
+	// The transport asks for a gzip response itself, and decompresses it
+	if statErr == nil {
+		// Revalidate the expired entry rather than downloading it again
+		setCacheValidators(req, cacheFile)
+	}
+
+	var resp *http.Response
+	// A key over its quota gives way to the next one of api_keys
+	for attempt := 1; ; attempt++ {
+		key := getAPIKey()
+		req.Header.Set("X-Secret", key)
+		resp, err = getHTTPClient().Do(req)
+		if err != nil {
+			return nil, false, fmt.Errorf("could not fetch translation: %w", err)
+		}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("could not fetch translation: %w", err)
+		if err := countAPIRequest(); err != nil {
+			// Log the error, but don't fail the command
+			slog.Warn("could not count API request", "err", err)
+		}
+
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= len(getAPIKeys()) || !rotateAPIKey(key) {
+			break
+		}
+		resp.Body.Close()
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && statErr == nil {
+		now := time.Now()
+		if err := os.Chtimes(cacheFile, now, now); err != nil {
+			// Log the error, but don't fail the command
+			slog.Warn("could not refresh cache file", "err", err)
+		}
+		translations, err := readTranslationCache(cacheFile)
+		return translations, false, err
+	}
+
 	if resp.StatusCode == http.StatusNoContent {
-		return nil, fmt.Errorf("no translation found")
+		return nil, false, errNoTranslation
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("bad status code: %d", resp.StatusCode)
+		return nil, false, newAPIError(resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("could not read response body: %w", err)
+		return nil, false, fmt.Errorf("could not read response body: %w", err)
 	}
 
 	// Write to cache
-	if err := os.WriteFile(cacheFile, body, 0644); err != nil {
+	if err := writeCacheFile(cacheFile, body); err != nil {
 		// Log this error, but don't fail the command
-		fmt.Printf("could not write cache file: %v", err)
+		slog.Warn("could not write cache file", "err", err)
+	}
+	if err := writeCacheValidators(cacheFile, resp.Header); err != nil {
+		// Log the error, but don't fail the command
+		slog.Warn("could not write cache validators", "err", err)
 	}
 
 	var translations TranslationResponse
 	if err := json.Unmarshal(body, &translations); err != nil {
-		return nil, fmt.Errorf("could not unmarshal json: %w", err)
+		return nil, false, fmt.Errorf("could not unmarshal json: %w", err)
 	}
 
-	return translations, nil
+	return translations, false, nil
 }
 
-func addSearchHistory(term, dictionary string) error {
-	stmt, err := db.Prepare("INSERT INTO search_history(searched_term, dict, date) VALUES(?, ?, ?)")
+func addSearchHistory(term, dictionary string, cached bool) error {
+	stmt, err := db.Prepare("INSERT INTO search_history(searched_term, dict, date, found, http_status) VALUES(?, ?, ?, 1, ?)")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(term, dictionary, time.Now())
-	return err
+	if _, err := stmt.Exec(term, dictionary, time.Now(), http.StatusOK); err != nil {
+		return err
+	}
+	logLookupEvent(term, dictionary, true, cached)
+	return nil
 }
 
-func getHalfWidth() int {
+func getTermWidth() int {
 	termWidth, _, err := term.GetSize(int(os.Stdout.Fd()))
 	if err != nil {
 		termWidth = 80 // Fallback to 80 columns if unknown
 	}
 
-	return termWidth / 2
+	return termWidth
 }
 
-func newTable() table.Writer {
-	halfWidth := getHalfWidth()
+// newTable returns a two-column table fitting width.
+func newTable(w io.Writer, width int, aligns ...text.Align) table.Writer {
+	columnWidth := max((width-getTableOverhead())/2, 1)
 	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
+	t.SetOutputMirror(w)
 	// Force each column to take 50% of terminal width
-	t.SetColumnConfigs([]table.ColumnConfig{
-		{Number: 1, WidthMax: halfWidth, WidthMin: halfWidth},
-		{Number: 2, WidthMax: halfWidth, WidthMin: halfWidth},
-	})
-	// Set no-border style
-	t.SetStyle(table.Style{
-		Name:   "NoBorders",
-		Box:    table.BoxStyle{},
-		Color:  table.ColorOptions{},
-		Format: table.FormatOptions{},
-		Options: table.Options{
-			DrawBorder:      false,
-			SeparateColumns: false,
-			SeparateHeader:  false,
-			SeparateFooter:  false,
-		},
-	})
+	configs := []table.ColumnConfig{
+		{Number: 1, WidthMax: columnWidth, WidthMin: columnWidth, WidthMaxEnforcer: wrapCell},
+		{Number: 2, WidthMax: columnWidth, WidthMin: columnWidth, WidthMaxEnforcer: wrapCell},
+	}
+	for i, align := range aligns {
+		configs[i].Align = align
+	}
+	t.SetColumnConfigs(configs)
+	t.SetStyle(getTableStyle())
 	return t
 }
 
-func displayTranslation(translations TranslationResponse, dictKey string) {
-
+func displayTranslation(w io.Writer, translations TranslationResponse, dictKey string, word string, width int) {
+	section, entry := 0, 0
+	entries := countEntries(translations)
+	corrections := loadCorrections(dictKey)
 	for _, lang := range translations {
-		color.New(color.FgRed, color.Bold).Printf("\n%s > %s\n", strings.ToUpper(lang.Lang), strings.ToUpper(strings.Replace(dictKey, lang.Lang, "", 1)))
-		for _, hit := range lang.Hits {
-			if len(hit.Roms) > 0 {
-				for i, rom := range hit.Roms {
-					color.New(color.FgYellow, color.Bold).Printf("\n%s. %s\n", toRoman(i+1), rom.Headword)
-					for _, arab := range rom.Arabs {
-						color.New(color.FgGreen).Println(parseHTML(arab.Header))
-						t := newTable()
-						for _, translation := range arab.Translations {
-							t.AppendRow(table.Row{parseHTML(translation.Source), parseHTML(translation.Target)})
-						}
-						t.Render()
+		targetLang := getTargetLang(dictKey, lang.Lang)
+		langs := [2]string{lang.Lang, targetLang}
+		color.New(color.FgRed, color.Bold).Fprintf(w, "\n%s > %s\n", strings.ToUpper(lang.Lang), strings.ToUpper(targetLang))
+		sections, others := groupByWordClass(lang.Hits)
+		for _, s := range sections {
+			section++
+			shown := isSectionShown(section)
+			// Entries without word classes don't need a section
+			if len(sections) > 1 || s.WordClass != "" {
+				wordClass := s.WordClass
+				if wordClass == "" {
+					wordClass = "other"
+				}
+				color.New(color.FgCyan, color.Bold).Fprintf(w, "\n%d. %s (%d)", section, wordClass, len(s.Roms))
+				if !shown {
+					color.New(color.Faint).Fprintf(w, " - .show %d to expand", section)
+				}
+				fmt.Fprintln(w)
+			}
+			if !shown {
+				entry += len(s.Roms)
+				continue
+			}
+			for i, rom := range s.Roms {
+				entry++
+				getHeadwordColor(rom).Fprintf(w, "\n%s. %s", toRoman(i+1), hyperlink(getEntryURL(dictKey, rom.Headword), rom.Headword))
+				fmt.Fprintln(w, formatPhonetics(rom)+formatFrequency(rom, lang.Lang)+formatEntryNumber(entry, entries)+formatOrigin(rom.Origin))
+				for _, arab := range rom.Arabs {
+					color.New(color.FgGreen).Fprintln(w, parseHTML(arab.Header))
+					var rows []table.Row
+					for _, translation := range arab.Translations {
+						rows = append(rows, table.Row{highlightHTML(translation.Source, word), parseHTML(translation.Target)})
 					}
+					renderRows(w, rows, langs, width)
+				}
+				if correction, ok := corrections[normalizeHeadword(rom.Headword)]; ok {
+					fmt.Fprintln(w, formatCorrection(correction))
 				}
-			} else {
-				t := newTable()
-				t.AppendRow(table.Row{parseHTML(hit.Source), parseHTML(hit.Target)})
-				t.Render()
 			}
 		}
+		for _, hit := range others {
+			renderRows(w, []table.Row{{highlightHTML(hit.Source, word) + formatOrigin(getHitOrigin(hit)), parseHTML(hit.Target)}}, langs, width)
+		}
 	}
-	fmt.Println()
+	fmt.Fprintln(w)
 }
 
 func toRoman(num int) string {
@@ -399,41 +948,111 @@ func toRoman(num int) string {
 }
 
 func parseHTML(htmlString string) string {
+	return flattenHTML(htmlString, "", false)
+}
+
+// highlightHTML flattens htmlString like parseHTML, but emphasizes the
+// occurrences of word as well as the forms PONS marks as headword or tilde
+// (the headword repeated, possibly inflected, inside examples).
+func highlightHTML(htmlString, word string) string {
+	return flattenHTML(htmlString, word, true)
+}
+
+func flattenHTML(htmlString, word string, highlighted bool) string {
 	doc, err := html.Parse(strings.NewReader(htmlString))
 	if err != nil {
 		return htmlString // return raw string on error
 	}
-	var f func(*html.Node)
+	highlight := color.New(color.Bold, color.Underline)
+	var wordRe *regexp.Regexp
+	if highlighted && word != "" {
+		wordRe = regexp.MustCompile("(?i)" + regexp.QuoteMeta(word))
+	}
+	var f func(*html.Node, *strings.Builder, bool)
 	var sb strings.Builder
-	f = func(n *html.Node) {
+	f = func(n *html.Node, sb *strings.Builder, marked bool) {
+		if n.Type == html.ElementNode && n.Data == "acronym" && config.ExpandAbbrev {
+			if meaning, ok := expandAbbreviation(n); ok {
+				sb.WriteString(meaning)
+				return
+			}
+		}
+		if n.Type == html.ElementNode && hasClass(n, "genus") && config.GenderColors {
+			if c, ok := getGenderColor(getGender(nodeText(n))); ok {
+				var inner strings.Builder
+				for child := n.FirstChild; child != nil; child = child.NextSibling {
+					f(child, &inner, marked)
+				}
+				sb.WriteString(c.Sprint(inner.String()))
+				return
+			}
+		}
+		if highlighted && n.Type == html.ElementNode && n.Data == "strong" && (hasClass(n, "headword") || hasClass(n, "tilde")) {
+			marked = true
+		}
 		if n.Type == html.TextNode {
-			sb.WriteString(n.Data)
+			switch {
+			case marked:
+				sb.WriteString(highlight.Sprint(n.Data))
+			case wordRe != nil:
+				sb.WriteString(wordRe.ReplaceAllStringFunc(n.Data, func(m string) string {
+					return highlight.Sprint(m)
+				}))
+			default:
+				sb.WriteString(n.Data)
+			}
 		}
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			f(c)
+			f(c, sb, marked)
 		}
 	}
-	f(doc)
+	f(doc, &sb, false)
 	return sb.String()
 }
 
+// nodeText returns the raw text of a node and its descendants.
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(nodeText(c))
+	}
+	return sb.String()
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == "class" {
+			for _, c := range strings.Fields(attr.Val) {
+				if c == class {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func readTranslationCache(cacheFile string) (TranslationResponse, error) {
+	body, err := readCacheFile(cacheFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var translations TranslationResponse
+	if err := json.Unmarshal(body, &translations); err != nil {
+		return nil, fmt.Errorf("could not unmarshal cached json: %w", err)
+	}
+	return translations, nil
+}
+
 func getTranslationCacheKey(word, dict string) string {
 	hash := sha256.Sum256([]byte(word + "_" + dict))
 	return hex.EncodeToString(hash[:])
 }
 
-func handleHelpCommand() {
-	color.New(color.FgYellow).Println("Available commands:")
-	fmt.Println(".help - Show this help message")
-	fmt.Println(".quit - Exit the program")
-	fmt.Println(".dict - List available dictionaries")
-	fmt.Println(".dict <key> - Set the current dictionary")
-	fmt.Println(".history - Show search history")
-	fmt.Println(".cards <dict> <origin> [<days>] - Enter flashcards mode")
-	fmt.Println(".set - Show current settings")
-	fmt.Println(".set <var> <value> - Set a configuration variable")
-}
-
 func handleCardsCommand(args []string) error {
 	if len(args) < 2 || len(args) > 3 {
 		return fmt.Errorf("usage: .cards <dict> <origin> [<days>]")
@@ -470,7 +1089,7 @@ func handleCardsCommand(args []string) error {
 		translations, err := getTranslation(word, dict)
 		if err != nil {
 			// if a word from history is not available anymore in PONS api, just skip it
-			if err.Error() == "no translation found" {
+			if errors.Is(err, errNoTranslation) {
 				continue
 			}
 			return err
@@ -492,6 +1111,11 @@ func handleCardsCommand(args []string) error {
 
 		displayCard(translations, dict, origin, false)
 
+		if err := recordActivity(0, 1); err != nil {
+			// Log the error, but don't fail the command
+			slog.Warn("could not record activity", "err", err)
+		}
+
 		color.New(color.FgYellow).Println("press any key to continue, or ESC to exit from Cards mode")
 
 		_, key, err = keyboard.GetSingleKey()
@@ -512,7 +1136,7 @@ func getRandomWord(dict string, days int) (string, error) {
 	var query string
 	var args []interface{}
 
-	query = "SELECT searched_term FROM search_history WHERE dict = ? "
+	query = "SELECT searched_term FROM search_history WHERE dict = ? AND found = 1 "
 	args = append(args, dict)
 
 	if days > 0 {
@@ -534,46 +1158,53 @@ func getRandomWord(dict string, days int) (string, error) {
 
 func displayCard(translations TranslationResponse, dict string, origin string, partial bool) {
 	for _, lang := range translations {
+		targetLang := getTargetLang(dict, lang.Lang)
+		langs := [2]string{lang.Lang, targetLang}
+		if partial {
+			// Only the origin language is shown, in the first column
+			langs = [2]string{origin, ""}
+		}
 		if !partial {
-			color.New(color.FgRed, color.Bold).Printf("\n%s > %s\n", strings.ToUpper(lang.Lang), strings.ToUpper(strings.Replace(dict, lang.Lang, "", 1)))
+			color.New(color.FgRed, color.Bold).Printf("\n%s > %s\n", strings.ToUpper(lang.Lang), strings.ToUpper(targetLang))
 		}
 		for _, hit := range lang.Hits {
 			if len(hit.Roms) > 0 {
 				for i, rom := range hit.Roms {
 					if !partial {
-						color.New(color.FgYellow, color.Bold).Printf("\n%s. %s\n", toRoman(i+1), rom.Headword)
+						getHeadwordColor(rom).Printf("\n%s. %s", toRoman(i+1), rom.Headword)
+						fmt.Println(formatPhonetics(rom) + formatFrequency(rom, lang.Lang))
 					}
 					for _, arab := range rom.Arabs {
 						if !partial {
 							color.New(color.FgGreen).Println(parseHTML(arab.Header))
 						}
-						t := newTable()
+						var rows []table.Row
 						for _, translation := range arab.Translations {
 							if partial {
 								if lang.Lang == origin {
-									t.AppendRow(table.Row{parseHTML(translation.Source), ""})
+									rows = append(rows, table.Row{parseHTML(translation.Source), ""})
 								} else {
-									t.AppendRow(table.Row{parseHTML(translation.Target), ""})
+									rows = append(rows, table.Row{parseHTML(translation.Target), ""})
 								}
 							} else {
-								t.AppendRow(table.Row{parseHTML(translation.Source), parseHTML(translation.Target)})
+								rows = append(rows, table.Row{parseHTML(translation.Source), parseHTML(translation.Target)})
 							}
 						}
-						t.Render()
+						renderRows(os.Stdout, rows, langs, getTermWidth())
 					}
 				}
 			} else {
-				t := newTable()
+				var row table.Row
 				if partial {
 					if lang.Lang == origin {
-						t.AppendRow(table.Row{parseHTML(hit.Source), ""})
+						row = table.Row{parseHTML(hit.Source), ""}
 					} else {
-						t.AppendRow(table.Row{parseHTML(hit.Target), ""})
+						row = table.Row{parseHTML(hit.Target), ""}
 					}
 				} else {
-					t.AppendRow(table.Row{parseHTML(hit.Source), parseHTML(hit.Target)})
+					row = table.Row{parseHTML(hit.Source), parseHTML(hit.Target)}
 				}
-				t.Render()
+				renderRows(os.Stdout, []table.Row{row}, langs, getTermWidth())
 			}
 		}
 	}
@@ -581,7 +1212,7 @@ func displayCard(translations TranslationResponse, dict string, origin string, p
 }
 
 func handleHistoryCommand() error {
-	rows, err := db.Query("SELECT searched_term, dict, date FROM search_history ORDER BY date DESC")
+	rows, err := db.Query("SELECT id, searched_term, dict, date, found FROM search_history ORDER BY date DESC")
 	if err != nil {
 		return fmt.Errorf("could not query search history: %w", err)
 	}
@@ -589,21 +1220,86 @@ func handleHistoryCommand() error {
 
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
-	t.AppendHeader(table.Row{"Searched Term", "Dictionary", "Date"})
+	t.AppendHeader(table.Row{"#", "Searched Term", "Dictionary", "Time"})
+	t.SetColumnConfigs([]table.ColumnConfig{{Number: 1, Align: text.AlignRight}})
 
+	// Searches are grouped under the day they were made
+	location := getLocation()
+	now := time.Now().In(location)
+	lastDay := ""
 	for rows.Next() {
+		var id int
 		var term, dict string
 		var date time.Time
-		if err := rows.Scan(&term, &dict, &date); err != nil {
+		var found bool
+		if err := rows.Scan(&id, &term, &dict, &date, &found); err != nil {
 			return fmt.Errorf("could not scan row: %w", err)
 		}
-		t.AppendRow(table.Row{term, dict, date.Format("2006-01-02 15:04:05")})
+		if !found {
+			term = color.New(color.FgRed).Sprint(term)
+		}
+		date = date.In(location)
+		if day := formatDay(date, now); day != lastDay {
+			if lastDay != "" {
+				t.AppendSeparator()
+			}
+			heading := color.New(color.FgYellow).Sprint(day)
+			t.AppendRow(table.Row{heading, heading, heading, heading}, table.RowConfig{AutoMerge: true, AutoMergeAlign: text.AlignLeft})
+			t.AppendSeparator()
+			lastDay = day
+		}
+		t.AppendRow(table.Row{id, term, dict, fmt.Sprintf("%s (%s)", date.Format("15:04"), formatRelativeTime(date, now))})
+	}
+	if err := rows.Err(); err != nil {
+		return err
 	}
 
 	t.Render()
 	return nil
 }
 
+// getSearchHistoryEntry returns the search numbered n by .history, or the
+// last search if n is 0.
+func getSearchHistoryEntry(n int) (string, string, error) {
+	var term, dict string
+	var err error
+	if n == 0 {
+		err = db.QueryRow("SELECT searched_term, dict FROM search_history ORDER BY date DESC LIMIT 1").Scan(&term, &dict)
+	} else {
+		err = db.QueryRow("SELECT searched_term, dict FROM search_history WHERE id = ?", n).Scan(&term, &dict)
+	}
+	if err == sql.ErrNoRows && n == 0 {
+		return "", "", fmt.Errorf("no search in the history")
+	} else if err == sql.ErrNoRows {
+		return "", "", fmt.Errorf("no search #%d in the history", n)
+	} else if err != nil {
+		return "", "", fmt.Errorf("could not query search history: %w", err)
+	}
+	return term, dict, nil
+}
+
+// handleRedoCommand looks a search of the history up again, the last one by
+// default.
+func handleRedoCommand(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: .redo [<n>]")
+	}
+	n := 0
+	if len(args) == 1 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+			return fmt.Errorf("invalid search number: %s", args[0])
+		}
+	}
+
+	term, dict, err := getSearchHistoryEntry(n)
+	if err != nil {
+		return err
+	}
+	printNotice("%s (%s)\n", term, dict)
+	return lookupWord(term, dict)
+}
+
 func handleSetCommand(args []string) error {
 	if len(args) == 0 {
 		color.New(color.FgYellow).Println("Usage: .set <variable> <value>")
@@ -615,9 +1311,139 @@ func handleSetCommand(args []string) error {
 		fmt.Printf(": %d\n", config.CmdHistoryLimit)
 		color.New(color.FgGreen).Printf("search_history_limit")
 		fmt.Printf(": %d\n", config.SearchHistoryLimit)
+		color.New(color.FgGreen).Printf("transcript")
+		fmt.Printf(": %s\n", config.Transcript)
+		color.New(color.FgGreen).Printf("rtl_mode")
+		fmt.Printf(": %s\n", config.RTLMode)
+		color.New(color.FgGreen).Printf("transliterate")
+		fmt.Printf(": %s\n", formatBool(config.Transliterate))
+		color.New(color.FgGreen).Printf("mt_provider")
+		fmt.Printf(": %s\n", config.MTProvider)
+		color.New(color.FgGreen).Printf("mt_api_key")
+		fmt.Printf(": %s\n", config.MTAPIKey)
+		color.New(color.FgGreen).Printf("mt_url")
+		fmt.Printf(": %s\n", config.MTURL)
+		color.New(color.FgGreen).Printf("provider")
+		fmt.Printf(": %s\n", config.Provider)
+		color.New(color.FgGreen).Printf("local_dict_dir")
+		fmt.Printf(": %s\n", config.LocalDictDir)
+		color.New(color.FgGreen).Printf("dict_server")
+		fmt.Printf(": %s\n", config.DictServer)
+		color.New(color.FgGreen).Printf("spelling_variants")
+		fmt.Printf(": %s\n", formatBool(config.SpellingVariants))
+		color.New(color.FgGreen).Printf("show_examples")
+		fmt.Printf(": %s\n", formatBool(config.ShowExamples))
+		color.New(color.FgGreen).Printf("expand_abbrev")
+		fmt.Printf(": %s\n", formatBool(config.ExpandAbbrev))
+		color.New(color.FgGreen).Printf("gender_colors")
+		fmt.Printf(": %s\n", formatBool(config.GenderColors))
+		color.New(color.FgGreen).Printf("gender_palette")
+		fmt.Printf(": %s\n", config.GenderPalette)
+		color.New(color.FgGreen).Printf("collapse_sections")
+		fmt.Printf(": %s\n", formatBool(config.CollapseSections))
+		color.New(color.FgGreen).Printf("default_dict")
+		fmt.Printf(": %s\n", config.DefaultDict)
+		color.New(color.FgGreen).Printf("sync_file")
+		fmt.Printf(": %s\n", config.SyncFile)
+		color.New(color.FgGreen).Printf("anki_url")
+		fmt.Printf(": %s\n", config.AnkiURL)
+		color.New(color.FgGreen).Printf("anki_deck")
+		fmt.Printf(": %s\n", config.AnkiDeck)
+		color.New(color.FgGreen).Printf("wotd_source")
+		fmt.Printf(": %s\n", config.WOTDSource)
+		color.New(color.FgGreen).Printf("wotd_on_startup")
+		fmt.Printf(": %s\n", formatBool(config.WOTDOnStartup))
+		color.New(color.FgGreen).Printf("daily_goal")
+		fmt.Printf(": %d\n", config.DailyGoal)
+		color.New(color.FgGreen).Printf("cloze_cards")
+		fmt.Printf(": %s\n", formatBool(config.ClozeCards))
+		color.New(color.FgGreen).Printf("queue_auto_flush")
+		fmt.Printf(": %s\n", formatBool(config.QueueAutoFlush))
+		color.New(color.FgGreen).Printf("monthly_request_budget")
+		fmt.Printf(": %d\n", config.MonthlyRequestBudget)
+		color.New(color.FgGreen).Printf("connect_timeout")
+		fmt.Printf(": %d\n", config.ConnectTimeout)
+		color.New(color.FgGreen).Printf("http_timeout")
+		fmt.Printf(": %d\n", config.HTTPTimeout)
+		color.New(color.FgGreen).Printf("proxy_url")
+		fmt.Printf(": %s\n", config.ProxyURL)
+		color.New(color.FgGreen).Printf("debug")
+		fmt.Printf(": %s\n", formatBool(config.Debug))
+		color.New(color.FgGreen).Printf("log_level")
+		fmt.Printf(": %s\n", config.LogLevel)
+		color.New(color.FgGreen).Printf("hyperlinks")
+		fmt.Printf(": %s\n", config.Hyperlinks)
+		color.New(color.FgGreen).Printf("table_style")
+		fmt.Printf(": %s\n", config.TableStyle)
+		color.New(color.FgGreen).Printf("column_separator")
+		fmt.Printf(": %s\n", formatBool(config.ColumnSeparator))
+		color.New(color.FgGreen).Printf("row_separator")
+		fmt.Printf(": %s\n", formatBool(config.RowSeparator))
+		color.New(color.FgGreen).Printf("cell_padding")
+		fmt.Printf(": %d\n", config.CellPadding)
+		color.New(color.FgGreen).Printf("table_header")
+		fmt.Printf(": %s\n", formatBool(config.TableHeader))
+		color.New(color.FgGreen).Printf("accessible")
+		fmt.Printf(": %s\n", formatBool(config.Accessible))
+		color.New(color.FgGreen).Printf("editing_mode")
+		fmt.Printf(": %s\n", config.EditingMode)
+		color.New(color.FgGreen).Printf("keybindings")
+		fmt.Printf(": %s\n", formatKeybindings())
+		color.New(color.FgGreen).Printf("clear_between_results")
+		fmt.Printf(": %s\n", formatBool(config.ClearBetweenResults))
+		color.New(color.FgGreen).Printf("autosuggest")
+		fmt.Printf(": %s\n", formatBool(config.Autosuggest))
+		color.New(color.FgGreen).Printf("date_format")
+		fmt.Printf(": %s\n", config.DateFormat)
+		color.New(color.FgGreen).Printf("timezone")
+		fmt.Printf(": %s\n", config.Timezone)
+		color.New(color.FgGreen).Printf("exact")
+		fmt.Printf(": %s\n", formatBool(config.Exact))
+		color.New(color.FgGreen).Printf("query_case")
+		fmt.Printf(": %s\n", config.QueryCase)
+		color.New(color.FgGreen).Printf("normalize_input")
+		fmt.Printf(": %s\n", formatBool(config.NormalizeInput))
+		color.New(color.FgGreen).Printf("strip_clitics")
+		fmt.Printf(": %s\n", formatBool(config.StripClitics))
+		color.New(color.FgGreen).Printf("show_origin")
+		fmt.Printf(": %s\n", formatBool(config.ShowOrigin))
+		color.New(color.FgGreen).Printf("sort")
+		fmt.Printf(": %s\n", config.Sort)
+		color.New(color.FgGreen).Printf("thesaurus")
+		fmt.Printf(": %s\n", formatThesauruses())
+		color.New(color.FgGreen).Printf("default_decks")
+		fmt.Printf(": %s\n", formatDefaultDecks())
+		color.New(color.FgGreen).Printf("deck_rules")
+		fmt.Printf(": %s\n", formatDeckRules())
+		color.New(color.FgGreen).Printf("phonetics")
+		fmt.Printf(": %s\n", formatBool(config.Phonetics))
+		color.New(color.FgGreen).Printf("frequency_annotation")
+		fmt.Printf(": %s\n", config.FrequencyAnnotation)
+		color.New(color.FgGreen).Printf("digest_file")
+		fmt.Printf(": %s\n", config.DigestFile)
+		color.New(color.FgGreen).Printf("event_log")
+		fmt.Printf(": %s\n", config.EventLog)
+		color.New(color.FgGreen).Printf("hook_command")
+		fmt.Printf(": %s\n", config.HookCommand)
+		color.New(color.FgGreen).Printf("hook_url")
+		fmt.Printf(": %s\n", config.HookURL)
+		color.New(color.FgGreen).Printf("api_keys")
+		fmt.Printf(": %s\n", config.APIKeys)
+		color.New(color.FgGreen).Printf("srs_algorithm")
+		fmt.Printf(": %s\n", config.SRSAlgorithm)
+		color.New(color.FgGreen).Printf("new_cards_per_day")
+		fmt.Printf(": %d\n", config.NewCardsPerDay)
+		color.New(color.FgGreen).Printf("reviews_per_day")
+		fmt.Printf(": %d\n", config.ReviewsPerDay)
+		color.New(color.FgGreen).Printf("compound_lookups")
+		fmt.Printf(": %s\n", formatBool(config.CompoundLookups))
 		return nil
 	}
 
+	if len(args) == 1 && args[0] == "--reset" {
+		return resetConfig()
+	}
+
 	if len(args) != 2 {
 		return fmt.Errorf("invalid number of arguments")
 	}
@@ -625,37 +1451,362 @@ func handleSetCommand(args []string) error {
 	varName := args[0]
 	varValue := args[1]
 
+	previousConfig := config
 	switch varName {
 	case "api_key":
 		config.APIKey = varValue
 	case "cache_ttl":
 		val, err := strconv.Atoi(varValue)
 		if err != nil {
-			return fmt.Errorf("invalid value for cache_ttl: %s", varValue)
+			return fmt.Errorf("invalid value for cache_ttl: %s", varValue)
+		}
+		config.CacheTTL = val
+	case "cmd_history_limit":
+		val, err := strconv.Atoi(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for cmd_history_limit: %s", varValue)
+		}
+		config.CmdHistoryLimit = val
+	case "search_history_limit":
+		val, err := strconv.Atoi(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for search_history_limit: %s", varValue)
+		}
+		config.SearchHistoryLimit = val
+	case "transcript":
+		if varValue == "off" {
+			varValue = ""
+		}
+		config.Transcript = varValue
+	case "rtl_mode":
+		if varValue != "off" && varValue != "swap" && varValue != "visual" {
+			return fmt.Errorf("invalid value for rtl_mode: %s (expected off, swap or visual)", varValue)
+		}
+		config.RTLMode = varValue
+	case "transliterate":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for transliterate: %s", varValue)
+		}
+		config.Transliterate = val
+	case "mt_provider":
+		if _, ok := sentenceTranslators[varValue]; !ok {
+			return fmt.Errorf("invalid value for mt_provider: %s (expected pons, deepl or libretranslate)", varValue)
+		}
+		config.MTProvider = varValue
+	case "mt_api_key":
+		config.MTAPIKey = varValue
+	case "mt_url":
+		config.MTURL = varValue
+	case "provider":
+		if _, ok := providers[varValue]; !ok {
+			return fmt.Errorf("unknown provider: %s", varValue)
+		}
+		config.Provider = varValue
+	case "local_dict_dir":
+		config.LocalDictDir = varValue
+	case "dict_server":
+		config.DictServer = varValue
+	case "spelling_variants":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for spelling_variants: %s", varValue)
+		}
+		config.SpellingVariants = val
+	case "show_examples":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for show_examples: %s", varValue)
+		}
+		config.ShowExamples = val
+	case "expand_abbrev":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for expand_abbrev: %s", varValue)
+		}
+		config.ExpandAbbrev = val
+	case "gender_colors":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for gender_colors: %s", varValue)
+		}
+		config.GenderColors = val
+	case "gender_palette":
+		if _, err := parseGenderPalette(varValue); err != nil {
+			return fmt.Errorf("invalid value for gender_palette: %w", err)
+		}
+		config.GenderPalette = varValue
+	case "collapse_sections":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for collapse_sections: %s", varValue)
+		}
+		config.CollapseSections = val
+	case "default_dict":
+		config.DefaultDict = varValue
+	case "sync_file":
+		config.SyncFile = varValue
+	case "anki_url":
+		config.AnkiURL = varValue
+	case "anki_deck":
+		config.AnkiDeck = varValue
+	case "wotd_source":
+		if varValue != "list" && varValue != "saved" {
+			return fmt.Errorf("invalid value for wotd_source: %s (expected list or saved)", varValue)
+		}
+		config.WOTDSource = varValue
+	case "wotd_on_startup":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for wotd_on_startup: %s", varValue)
+		}
+		config.WOTDOnStartup = val
+	case "daily_goal":
+		val, err := strconv.Atoi(varValue)
+		if err != nil || val < 0 {
+			return fmt.Errorf("invalid value for daily_goal: %s", varValue)
+		}
+		config.DailyGoal = val
+	case "cloze_cards":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for cloze_cards: %s", varValue)
+		}
+		config.ClozeCards = val
+	case "queue_auto_flush":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for queue_auto_flush: %s", varValue)
+		}
+		config.QueueAutoFlush = val
+	case "monthly_request_budget":
+		val, err := strconv.Atoi(varValue)
+		if err != nil || val < 0 {
+			return fmt.Errorf("invalid value for monthly_request_budget: %s", varValue)
+		}
+		config.MonthlyRequestBudget = val
+	case "connect_timeout":
+		val, err := strconv.Atoi(varValue)
+		if err != nil || val < 0 {
+			return fmt.Errorf("invalid value for connect_timeout: %s", varValue)
+		}
+		config.ConnectTimeout = val
+	case "http_timeout":
+		val, err := strconv.Atoi(varValue)
+		if err != nil || val < 0 {
+			return fmt.Errorf("invalid value for http_timeout: %s", varValue)
+		}
+		config.HTTPTimeout = val
+	case "proxy_url":
+		if err := checkProxyURL(varValue); err != nil {
+			return fmt.Errorf("invalid value for proxy_url: %w", err)
+		}
+		config.ProxyURL = varValue
+	case "debug":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for debug: %s", varValue)
+		}
+		config.Debug = val
+	case "log_level":
+		if _, err := parseLogLevel(varValue); err != nil {
+			return fmt.Errorf("invalid value for log_level: %w", err)
+		}
+		config.LogLevel = varValue
+	case "hyperlinks":
+		if !slices.Contains([]string{"auto", "on", "off"}, varValue) {
+			return fmt.Errorf("invalid value for hyperlinks: %s", varValue)
+		}
+		config.Hyperlinks = varValue
+	case "table_style":
+		if _, ok := tableStyles[varValue]; !ok && varValue != "none" {
+			return fmt.Errorf("invalid value for table_style: %s", varValue)
+		}
+		config.TableStyle = varValue
+	case "column_separator":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for column_separator: %s", varValue)
+		}
+		config.ColumnSeparator = val
+	case "row_separator":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for row_separator: %s", varValue)
+		}
+		config.RowSeparator = val
+	case "cell_padding":
+		val, err := strconv.Atoi(varValue)
+		if err != nil || val < 0 {
+			return fmt.Errorf("invalid value for cell_padding: %s", varValue)
+		}
+		config.CellPadding = val
+	case "table_header":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for table_header: %s", varValue)
+		}
+		config.TableHeader = val
+	case "accessible":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for accessible: %s", varValue)
+		}
+		config.Accessible = val
+	case "editing_mode":
+		if varValue != "emacs" && varValue != "vi" {
+			return fmt.Errorf("invalid value for editing_mode: %s", varValue)
+		}
+		config.EditingMode = varValue
+	case "keybindings":
+		return fmt.Errorf("keybindings are set in the [keybindings] section of %s", getConfigFile())
+	case "thesaurus":
+		return fmt.Errorf("thesauruses are set in the [thesaurus] section of %s", getConfigFile())
+	case "default_decks":
+		return fmt.Errorf("default decks are set in the [default_decks] section of %s", getConfigFile())
+	case "deck_rules":
+		return fmt.Errorf("deck rules are set in the [[deck_rules]] tables of %s", getConfigFile())
+	case "clear_between_results":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for clear_between_results: %s", varValue)
+		}
+		config.ClearBetweenResults = val
+	case "autosuggest":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for autosuggest: %s", varValue)
+		}
+		config.Autosuggest = val
+	case "date_format":
+		if varValue == "" {
+			return fmt.Errorf("invalid value for date_format: %s", varValue)
+		}
+		config.DateFormat = varValue
+	case "timezone":
+		if _, err := time.LoadLocation(varValue); err != nil {
+			return fmt.Errorf("invalid value for timezone: %s", varValue)
+		}
+		config.Timezone = varValue
+	case "exact":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for exact: %s", varValue)
 		}
-		config.CacheTTL = val
-	case "cmd_history_limit":
-		val, err := strconv.Atoi(varValue)
+		config.Exact = val
+	case "query_case":
+		if !slices.Contains(queryCases, varValue) {
+			return fmt.Errorf("invalid value for query_case: %s", varValue)
+		}
+		config.QueryCase = varValue
+	case "normalize_input":
+		val, err := parseBool(varValue)
 		if err != nil {
-			return fmt.Errorf("invalid value for cmd_history_limit: %s", varValue)
+			return fmt.Errorf("invalid value for normalize_input: %s", varValue)
 		}
-		config.CmdHistoryLimit = val
-	case "search_history_limit":
+		config.NormalizeInput = val
+	case "strip_clitics":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for strip_clitics: %s", varValue)
+		}
+		config.StripClitics = val
+	case "show_origin":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for show_origin: %s", varValue)
+		}
+		config.ShowOrigin = val
+	case "sort":
+		if !slices.Contains(sortOrders, varValue) {
+			return fmt.Errorf("invalid value for sort: %s", varValue)
+		}
+		config.Sort = varValue
+	case "phonetics":
+		val, err := parseBool(varValue)
+		if err != nil {
+			return fmt.Errorf("invalid value for phonetics: %s", varValue)
+		}
+		config.Phonetics = val
+	case "frequency_annotation":
+		if !slices.Contains(frequencyAnnotations, varValue) {
+			return fmt.Errorf("invalid value for frequency_annotation: %s", varValue)
+		}
+		config.FrequencyAnnotation = varValue
+	case "digest_file":
+		config.DigestFile = varValue
+	case "event_log":
+		config.EventLog = varValue
+	case "hook_command":
+		config.HookCommand = varValue
+	case "hook_url":
+		config.HookURL = varValue
+	case "api_keys":
+		config.APIKeys = varValue
+	case "srs_algorithm":
+		if _, ok := schedulers[varValue]; !ok {
+			return fmt.Errorf("invalid value for srs_algorithm: %s (expected %s)", varValue, strings.Join(getSchedulerNames(), " or "))
+		}
+		config.SRSAlgorithm = varValue
+	case "new_cards_per_day":
+		val, err := strconv.Atoi(varValue)
+		if err != nil || val < 0 {
+			return fmt.Errorf("invalid value for new_cards_per_day: %s", varValue)
+		}
+		config.NewCardsPerDay = val
+	case "reviews_per_day":
 		val, err := strconv.Atoi(varValue)
+		if err != nil || val < 0 {
+			return fmt.Errorf("invalid value for reviews_per_day: %s", varValue)
+		}
+		config.ReviewsPerDay = val
+	case "compound_lookups":
+		val, err := parseBool(varValue)
 		if err != nil {
-			return fmt.Errorf("invalid value for search_history_limit: %s", varValue)
+			return fmt.Errorf("invalid value for compound_lookups: %s", varValue)
 		}
-		config.SearchHistoryLimit = val
+		config.CompoundLookups = val
 	default:
 		return fmt.Errorf("unknown variable: %s", varName)
 	}
 
-	return writeConfig()
+	// Values the config file would be rejected with at the next start
+	if key, value, ok := findNegativeValue(); ok {
+		config = previousConfig
+		return fmt.Errorf("invalid value for %s: %d", key, value)
+	}
+
+	if err := writeConfig(); err != nil {
+		return err
+	}
+	if varName == "api_key" && config.APIKey != "" {
+		return checkAPIKey()
+	}
+	applyAccessibleMode()
+	applyEditingMode()
+	return nil
+}
+
+// parseBool parses the on/off values of boolean settings.
+func parseBool(value string) (bool, error) {
+	switch value {
+	case "on", "true", "1":
+		return true, nil
+	case "off", "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected on or off")
+}
+
+func formatBool(value bool) string {
+	if value {
+		return "on"
+	}
+	return "off"
 }
 
 func writeConfig() error {
-	appConfigDir := filepath.Join(xdg.ConfigHome, "pons-cli")
-	configFile := filepath.Join(appConfigDir, "config.toml")
+	configFile := getConfigFile()
 
 	file, err := os.Create(configFile)
 	if err != nil {
@@ -690,7 +1841,7 @@ func handleDictCommand(args []string) error {
 	dictKey := args[0]
 	for _, dict := range dictionaries {
 		if dict.Key == dictKey {
-			currentDict = dictKey
+			setCurrentDict(dictKey)
 			return nil
 		}
 	}
@@ -699,22 +1850,31 @@ func handleDictCommand(args []string) error {
 }
 
 func getDictionaries() ([]Dictionary, error) {
+	provider, err := getProvider()
+	if err != nil {
+		return nil, err
+	}
+	return provider.Dictionaries()
+}
+
+func getPONSDictionaries() ([]Dictionary, error) {
 	cacheFile, err := getCacheFile("dictionaries.json")
 	if err != nil {
 		return nil, err
 	}
 
-	cacheTTL := time.Duration(config.CacheTTL) * time.Second
-	if isCacheValid(cacheFile, cacheTTL) {
-		file, err := os.Open(cacheFile)
-		if err != nil {
-			return nil, fmt.Errorf("could not open cache file: %w", err)
-		}
-		defer file.Close()
+	overBudget, err := isBudgetExceeded()
+	if err != nil {
+		return nil, err
+	}
 
-		body, err := io.ReadAll(file)
+	cacheTTL := time.Duration(config.CacheTTL) * time.Second
+	// Over budget, expired entries are better than nothing
+	_, statErr := os.Stat(cacheFile)
+	if isCacheValid(cacheFile, cacheTTL) || (overBudget && statErr == nil) {
+		body, err := readCacheFile(cacheFile)
 		if err != nil {
-			return nil, fmt.Errorf("could not read cache file: %w", err)
+			return nil, err
 		}
 
 		var dictionaries []Dictionary
@@ -735,14 +1895,14 @@ func getDictionaries() ([]Dictionary, error) {
 	q.Add("language", "en")
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := getHTTPClient().Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("could not fetch dictionaries: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("bad status code: %d", resp.StatusCode)
+		return nil, newAPIError(resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -751,9 +1911,9 @@ func getDictionaries() ([]Dictionary, error) {
 	}
 
 	// Write to cache
-	if err := os.WriteFile(cacheFile, body, 0644); err != nil {
+	if err := writeCacheFile(cacheFile, body); err != nil {
 		// Log this error, but don't fail the command
-		fmt.Printf("could not write cache file: %v", err)
+		slog.Warn("could not write cache file", "err", err)
 	}
 
 	var dictionaries []Dictionary
@@ -765,11 +1925,15 @@ func getDictionaries() ([]Dictionary, error) {
 }
 
 func getCacheFile(name string) (string, error) {
-	appCacheDir := filepath.Join(xdg.CacheHome, "pons-cli")
+	appCacheDir := getAppCacheDir()
 	return filepath.Join(appCacheDir, name), nil
 }
 
 func isCacheValid(path string, ttl time.Duration) bool {
+	// Fixtures bypass the cache, so that every lookup is recorded or replayed
+	if bypassCache.Load() || usingFixtures() {
+		return false
+	}
 	info, err := os.Stat(path)
 	if os.IsNotExist(err) {
 		return false
@@ -781,7 +1945,7 @@ func isCacheValid(path string, ttl time.Duration) bool {
 }
 
 func getDataFile(name string) (string, error) {
-	appDataDir := filepath.Join(xdg.DataHome, "pons-cli")
+	appDataDir := getAppDataDir()
 	return filepath.Join(appDataDir, name), nil
 }
 
@@ -789,6 +1953,10 @@ func setup() error {
 	if err := setupConfig(); err != nil {
 		return err
 	}
+	// The logs go to the state directory, set up first
+	if err := setupStateDir(); err != nil {
+		return err
+	}
 	if err := setupCache(); err != nil {
 		return err
 	}
@@ -807,31 +1975,40 @@ func setupDatabase() error {
 		return fmt.Errorf("could not get db file path: %w", err)
 	}
 
-	db, err = sql.Open("sqlite3", dbFile)
+	// WAL journaling lets other instances read while one writes, and the
+	// busy timeout makes them wait for each other instead of failing with
+	// "database is locked". Foreign keys are off by default in SQLite, and
+	// are needed for the review log to go along with its flashcards
+	db, err = sql.Open("sqlite3", "file:"+dbFile+"?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on")
 	if err != nil {
 		return fmt.Errorf("could not open database: %w", err)
 	}
+	// A single connection serializes the writes of this instance
+	db.SetMaxOpenConns(1)
 
-	// Create table if not exists
-	statement, err := db.Prepare(`
-		CREATE TABLE IF NOT EXISTS search_history (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			searched_term TEXT NOT NULL,
-			dict TEXT NOT NULL,
-			date DATETIME NOT NULL
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("could not prepare statement: %w", err)
-	}
-	_, err = statement.Exec()
-	if err != nil {
-		return fmt.Errorf("could not execute statement: %w", err)
+	if err := migrateDatabase(); err != nil {
+		return err
 	}
 
-	// Clean up old history
+	// Clean up old history, under the lock since another instance could be
+	// cleaning it up or importing the history file too
+	return withLock(func() error {
+		if err := importHistoryFile(); err != nil {
+			slog.Warn("could not import command history", "err", err)
+		}
+		if err := cleanupSearchHistory(); err != nil {
+			return err
+		}
+		if err := cleanupCommandHistory(); err != nil {
+			return err
+		}
+		return cleanupRequestLog()
+	})
+}
+
+func cleanupSearchHistory() error {
 	var count int
-	err = db.QueryRow("SELECT COUNT(*) FROM search_history").Scan(&count)
+	err := db.QueryRow("SELECT COUNT(*) FROM search_history").Scan(&count)
 	if err != nil {
 		return fmt.Errorf("could not count search history: %w", err)
 	}
@@ -855,7 +2032,7 @@ func setupDatabase() error {
 }
 
 func setupDataDir() error {
-	appDataDir := filepath.Join(xdg.DataHome, "pons-cli")
+	appDataDir := getAppDataDir()
 	if err := os.MkdirAll(appDataDir, 0755); err != nil {
 		return fmt.Errorf("could not create app data dir: %w", err)
 	}
@@ -863,21 +2040,37 @@ func setupDataDir() error {
 	return nil
 }
 
+func setupStateDir() error {
+	appStateDir := getAppStateDir()
+	if err := os.MkdirAll(appStateDir, 0755); err != nil {
+		return fmt.Errorf("could not create app state dir: %w", err)
+	}
+	if err := setupLogging(); err != nil {
+		return err
+	}
+
+	if err := migrateToStateDir(historyFileName); err != nil {
+		slog.Warn("could not migrate command history", "err", err)
+	}
+
+	return nil
+}
+
 func setupCache() error {
-	appCacheDir := filepath.Join(xdg.CacheHome, "pons-cli")
+	appCacheDir := getAppCacheDir()
 	if err := os.MkdirAll(appCacheDir, 0755); err != nil {
 		return fmt.Errorf("could not create app cache dir: %w", err)
 	}
 
 	if err := cleanupExpiredCacheFiles(); err != nil {
-		log.Printf("Error cleaning up expired cache files: %v", err)
+		slog.Warn("could not clean up expired cache files", "err", err)
 	}
 
 	return nil
 }
 
 func cleanupExpiredCacheFiles() error {
-	appCacheDir := filepath.Join(xdg.CacheHome, "pons-cli")
+	appCacheDir := getAppCacheDir()
 	files, err := os.ReadDir(appCacheDir)
 	if err != nil {
 		return fmt.Errorf("could not read cache directory: %w", err)
@@ -888,16 +2081,26 @@ func cleanupExpiredCacheFiles() error {
 	for _, file := range files {
 		if !file.IsDir() {
 			filePath := filepath.Join(appCacheDir, file.Name())
+			if isValidatorsFile(filePath) {
+				// Removed along with their cache file
+				continue
+			}
 			info, err := file.Info()
 			if err != nil {
-				log.Printf("could not get file info for %s: %v", filePath, err)
+				slog.Warn("could not get file info", "file", filePath, "err", err)
 				continue
 			}
-			if time.Since(info.ModTime()) > cacheTTL {
+			ttl := cacheTTL
+			if hasCacheValidators(filePath) {
+				// Expired entries which can be revalidated are kept longer
+				ttl += revalidationGrace
+			}
+			if time.Since(info.ModTime()) > ttl {
 				err := os.Remove(filePath)
 				if err != nil {
-					log.Printf("could not remove expired cache file %s: %v", filePath, err)
+					slog.Warn("could not remove expired cache file", "file", filePath, "err", err)
 				}
+				removeCacheValidators(filePath)
 			}
 		}
 	}
@@ -909,14 +2112,71 @@ func setupConfig() error {
 	const defaultCacheTTL = 604800 // 7 days
 	const defaultCmdHistoryLimit = 100
 	const defaultSearchHistoryLimit = 1000
-
-	appConfigDir := filepath.Join(xdg.ConfigHome, "pons-cli")
-	if err := os.MkdirAll(appConfigDir, 0755); err != nil {
+	const defaultTranscript = ""
+	const defaultRTLMode = "swap"
+	const defaultTransliterate = false
+	const defaultMTProvider = "pons"
+	const defaultMTAPIKey = ""
+	const defaultMTURL = ""
+	const defaultProvider = "pons"
+	const defaultLocalDictDir = ""
+	const defaultDictServer = "dict.org:2628"
+	const defaultSpellingVariants = false
+	const defaultShowExamples = true
+	const defaultExpandAbbrev = false
+	const defaultGenderColors = true
+	const defaultGenderPalette = "m=blue,f=red,n=green"
+	const defaultCollapseSections = false
+	const defaultDefaultDict = ""
+	const defaultSyncFile = ""
+	const defaultAnkiURL = "http://localhost:8765"
+	const defaultAnkiDeck = "pons-cli"
+	const defaultWOTDSource = "list"
+	const defaultWOTDOnStartup = false
+	const defaultDailyGoal = 20
+	const defaultClozeCards = false
+	const defaultQueueAutoFlush = true
+	const defaultMonthlyRequestBudget = 0
+	const defaultConnectTimeout = 10
+	const defaultHTTPTimeout = 30
+	const defaultProxyURL = ""
+	const defaultDebug = false
+	const defaultLogLevel = "info"
+	const defaultHyperlinks = "auto"
+	const defaultTableStyle = "none"
+	const defaultColumnSeparator = false
+	const defaultRowSeparator = false
+	const defaultCellPadding = 0
+	const defaultTableHeader = false
+	const defaultAccessible = false
+	const defaultEditingMode = "emacs"
+	const defaultClearBetweenResults = false
+	const defaultAutosuggest = true
+	const defaultDateFormat = "2006-01-02"
+	const defaultTimezone = "Local"
+	const defaultExact = false
+	const defaultQueryCase = "typed"
+	const defaultNormalizeInput = true
+	const defaultStripClitics = false
+	const defaultShowOrigin = false
+	const defaultSort = "relevance"
+	const defaultPhonetics = true
+	const defaultFrequencyAnnotation = "off"
+	const defaultDigestFile = ""
+	const defaultEventLog = ""
+	const defaultHookCommand = ""
+	const defaultHookURL = ""
+	const defaultAPIKeys = ""
+	const defaultSRSAlgorithm = "sm2"
+	const defaultNewCardsPerDay = 0
+	const defaultReviewsPerDay = 0
+	const defaultCompoundLookups = false
+
+	configFile := getConfigFile()
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
 		return fmt.Errorf("could not create app config dir: %w", err)
 	}
 
-	configFile := filepath.Join(appConfigDir, "config.toml")
-
 	md, err := toml.DecodeFile(configFile, &config)
 
 	needsWrite := false
@@ -925,9 +2185,70 @@ func setupConfig() error {
 		config.CacheTTL = defaultCacheTTL
 		config.CmdHistoryLimit = defaultCmdHistoryLimit
 		config.SearchHistoryLimit = defaultSearchHistoryLimit
+		config.Transcript = defaultTranscript
+		config.RTLMode = defaultRTLMode
+		config.Transliterate = defaultTransliterate
+		config.MTProvider = defaultMTProvider
+		config.MTAPIKey = defaultMTAPIKey
+		config.MTURL = defaultMTURL
+		config.Provider = defaultProvider
+		config.LocalDictDir = defaultLocalDictDir
+		config.DictServer = defaultDictServer
+		config.SpellingVariants = defaultSpellingVariants
+		config.ShowExamples = defaultShowExamples
+		config.ExpandAbbrev = defaultExpandAbbrev
+		config.GenderColors = defaultGenderColors
+		config.GenderPalette = defaultGenderPalette
+		config.CollapseSections = defaultCollapseSections
+		config.DefaultDict = defaultDefaultDict
+		config.SyncFile = defaultSyncFile
+		config.AnkiURL = defaultAnkiURL
+		config.AnkiDeck = defaultAnkiDeck
+		config.WOTDSource = defaultWOTDSource
+		config.WOTDOnStartup = defaultWOTDOnStartup
+		config.DailyGoal = defaultDailyGoal
+		config.ClozeCards = defaultClozeCards
+		config.QueueAutoFlush = defaultQueueAutoFlush
+		config.MonthlyRequestBudget = defaultMonthlyRequestBudget
+		config.ConnectTimeout = defaultConnectTimeout
+		config.HTTPTimeout = defaultHTTPTimeout
+		config.ProxyURL = defaultProxyURL
+		config.Debug = defaultDebug
+		config.LogLevel = defaultLogLevel
+		config.Hyperlinks = defaultHyperlinks
+		config.TableStyle = defaultTableStyle
+		config.ColumnSeparator = defaultColumnSeparator
+		config.RowSeparator = defaultRowSeparator
+		config.CellPadding = defaultCellPadding
+		config.TableHeader = defaultTableHeader
+		config.Accessible = defaultAccessible
+		config.EditingMode = defaultEditingMode
+		config.ClearBetweenResults = defaultClearBetweenResults
+		config.Autosuggest = defaultAutosuggest
+		config.DateFormat = defaultDateFormat
+		config.Timezone = defaultTimezone
+		config.Exact = defaultExact
+		config.QueryCase = defaultQueryCase
+		config.NormalizeInput = defaultNormalizeInput
+		config.StripClitics = defaultStripClitics
+		config.ShowOrigin = defaultShowOrigin
+		config.Sort = defaultSort
+		config.Phonetics = defaultPhonetics
+		config.FrequencyAnnotation = defaultFrequencyAnnotation
+		config.DigestFile = defaultDigestFile
+		config.EventLog = defaultEventLog
+		config.HookCommand = defaultHookCommand
+		config.HookURL = defaultHookURL
+		config.APIKeys = defaultAPIKeys
+		config.SRSAlgorithm = defaultSRSAlgorithm
+		config.NewCardsPerDay = defaultNewCardsPerDay
+		config.ReviewsPerDay = defaultReviewsPerDay
+		config.CompoundLookups = defaultCompoundLookups
 		needsWrite = true
 	} else if err != nil {
 		return fmt.Errorf("could not decode config file: %w", err)
+	} else if err := validateConfig(md, configFile); err != nil {
+		return err
 	}
 
 	if !md.IsDefined("api_key") {
@@ -950,6 +2271,301 @@ func setupConfig() error {
 		needsWrite = true
 	}
 
+	if !md.IsDefined("transcript") {
+		config.Transcript = defaultTranscript
+		needsWrite = true
+	}
+
+	if !md.IsDefined("rtl_mode") {
+		config.RTLMode = defaultRTLMode
+		needsWrite = true
+	}
+
+	if !md.IsDefined("transliterate") {
+		config.Transliterate = defaultTransliterate
+		needsWrite = true
+	}
+
+	if !md.IsDefined("mt_provider") {
+		config.MTProvider = defaultMTProvider
+		needsWrite = true
+	}
+
+	if !md.IsDefined("mt_api_key") {
+		config.MTAPIKey = defaultMTAPIKey
+		needsWrite = true
+	}
+
+	if !md.IsDefined("mt_url") {
+		config.MTURL = defaultMTURL
+		needsWrite = true
+	}
+
+	if !md.IsDefined("provider") {
+		config.Provider = defaultProvider
+		needsWrite = true
+	}
+
+	if !md.IsDefined("local_dict_dir") {
+		config.LocalDictDir = defaultLocalDictDir
+		needsWrite = true
+	}
+
+	if !md.IsDefined("dict_server") {
+		config.DictServer = defaultDictServer
+		needsWrite = true
+	}
+
+	if !md.IsDefined("spelling_variants") {
+		config.SpellingVariants = defaultSpellingVariants
+		needsWrite = true
+	}
+
+	if !md.IsDefined("show_examples") {
+		config.ShowExamples = defaultShowExamples
+		needsWrite = true
+	}
+
+	if !md.IsDefined("expand_abbrev") {
+		config.ExpandAbbrev = defaultExpandAbbrev
+		needsWrite = true
+	}
+
+	if !md.IsDefined("gender_colors") {
+		config.GenderColors = defaultGenderColors
+		needsWrite = true
+	}
+
+	if !md.IsDefined("gender_palette") {
+		config.GenderPalette = defaultGenderPalette
+		needsWrite = true
+	}
+
+	if !md.IsDefined("collapse_sections") {
+		config.CollapseSections = defaultCollapseSections
+		needsWrite = true
+	}
+
+	if !md.IsDefined("default_dict") {
+		config.DefaultDict = defaultDefaultDict
+		needsWrite = true
+	}
+
+	if !md.IsDefined("sync_file") {
+		config.SyncFile = defaultSyncFile
+		needsWrite = true
+	}
+
+	if !md.IsDefined("anki_url") {
+		config.AnkiURL = defaultAnkiURL
+		needsWrite = true
+	}
+
+	if !md.IsDefined("anki_deck") {
+		config.AnkiDeck = defaultAnkiDeck
+		needsWrite = true
+	}
+
+	if !md.IsDefined("wotd_source") {
+		config.WOTDSource = defaultWOTDSource
+		needsWrite = true
+	}
+
+	if !md.IsDefined("wotd_on_startup") {
+		config.WOTDOnStartup = defaultWOTDOnStartup
+		needsWrite = true
+	}
+
+	if !md.IsDefined("daily_goal") {
+		config.DailyGoal = defaultDailyGoal
+		needsWrite = true
+	}
+
+	if !md.IsDefined("cloze_cards") {
+		config.ClozeCards = defaultClozeCards
+		needsWrite = true
+	}
+
+	if !md.IsDefined("queue_auto_flush") {
+		config.QueueAutoFlush = defaultQueueAutoFlush
+		needsWrite = true
+	}
+
+	if !md.IsDefined("monthly_request_budget") {
+		config.MonthlyRequestBudget = defaultMonthlyRequestBudget
+		needsWrite = true
+	}
+
+	if !md.IsDefined("connect_timeout") {
+		config.ConnectTimeout = defaultConnectTimeout
+		needsWrite = true
+	}
+
+	if !md.IsDefined("http_timeout") {
+		config.HTTPTimeout = defaultHTTPTimeout
+		needsWrite = true
+	}
+
+	if !md.IsDefined("proxy_url") {
+		config.ProxyURL = defaultProxyURL
+		needsWrite = true
+	}
+
+	if !md.IsDefined("debug") {
+		config.Debug = defaultDebug
+		needsWrite = true
+	}
+
+	if !md.IsDefined("log_level") {
+		config.LogLevel = defaultLogLevel
+		needsWrite = true
+	}
+
+	if !md.IsDefined("hyperlinks") {
+		config.Hyperlinks = defaultHyperlinks
+		needsWrite = true
+	}
+
+	if !md.IsDefined("table_style") {
+		config.TableStyle = defaultTableStyle
+		needsWrite = true
+	}
+
+	if !md.IsDefined("column_separator") {
+		config.ColumnSeparator = defaultColumnSeparator
+		needsWrite = true
+	}
+
+	if !md.IsDefined("row_separator") {
+		config.RowSeparator = defaultRowSeparator
+		needsWrite = true
+	}
+
+	if !md.IsDefined("cell_padding") {
+		config.CellPadding = defaultCellPadding
+		needsWrite = true
+	}
+
+	if !md.IsDefined("table_header") {
+		config.TableHeader = defaultTableHeader
+		needsWrite = true
+	}
+
+	if !md.IsDefined("accessible") {
+		config.Accessible = defaultAccessible
+		needsWrite = true
+	}
+
+	if !md.IsDefined("editing_mode") {
+		config.EditingMode = defaultEditingMode
+		needsWrite = true
+	}
+
+	if !md.IsDefined("clear_between_results") {
+		config.ClearBetweenResults = defaultClearBetweenResults
+		needsWrite = true
+	}
+
+	if !md.IsDefined("autosuggest") {
+		config.Autosuggest = defaultAutosuggest
+		needsWrite = true
+	}
+
+	if !md.IsDefined("date_format") {
+		config.DateFormat = defaultDateFormat
+		needsWrite = true
+	}
+
+	if !md.IsDefined("timezone") {
+		config.Timezone = defaultTimezone
+		needsWrite = true
+	}
+
+	if !md.IsDefined("exact") {
+		config.Exact = defaultExact
+		needsWrite = true
+	}
+
+	if !md.IsDefined("query_case") {
+		config.QueryCase = defaultQueryCase
+		needsWrite = true
+	}
+
+	if !md.IsDefined("normalize_input") {
+		config.NormalizeInput = defaultNormalizeInput
+		needsWrite = true
+	}
+
+	if !md.IsDefined("strip_clitics") {
+		config.StripClitics = defaultStripClitics
+		needsWrite = true
+	}
+
+	if !md.IsDefined("show_origin") {
+		config.ShowOrigin = defaultShowOrigin
+		needsWrite = true
+	}
+
+	if !md.IsDefined("sort") {
+		config.Sort = defaultSort
+		needsWrite = true
+	}
+
+	if !md.IsDefined("phonetics") {
+		config.Phonetics = defaultPhonetics
+		needsWrite = true
+	}
+
+	if !md.IsDefined("frequency_annotation") {
+		config.FrequencyAnnotation = defaultFrequencyAnnotation
+		needsWrite = true
+	}
+
+	if !md.IsDefined("digest_file") {
+		config.DigestFile = defaultDigestFile
+		needsWrite = true
+	}
+
+	if !md.IsDefined("event_log") {
+		config.EventLog = defaultEventLog
+		needsWrite = true
+	}
+
+	if !md.IsDefined("hook_command") {
+		config.HookCommand = defaultHookCommand
+		needsWrite = true
+	}
+
+	if !md.IsDefined("hook_url") {
+		config.HookURL = defaultHookURL
+		needsWrite = true
+	}
+
+	if !md.IsDefined("api_keys") {
+		config.APIKeys = defaultAPIKeys
+		needsWrite = true
+	}
+
+	if !md.IsDefined("srs_algorithm") {
+		config.SRSAlgorithm = defaultSRSAlgorithm
+		needsWrite = true
+	}
+
+	if !md.IsDefined("new_cards_per_day") {
+		config.NewCardsPerDay = defaultNewCardsPerDay
+		needsWrite = true
+	}
+
+	if !md.IsDefined("reviews_per_day") {
+		config.ReviewsPerDay = defaultReviewsPerDay
+		needsWrite = true
+	}
+
+	if !md.IsDefined("compound_lookups") {
+		config.CompoundLookups = defaultCompoundLookups
+		needsWrite = true
+	}
+
 	if needsWrite {
 		return writeConfig()
 	}
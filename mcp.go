@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// MCP (Model Context Protocol) server: JSON-RPC 2.0 messages, one per line,
+// over stdin and stdout.
+
+const mcpProtocolVersion = "2024-11-05"
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+type mcpContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type mcpToolResult struct {
+	Content []mcpContent `json:"content"`
+	IsError bool         `json:"isError,omitempty"`
+}
+
+var mcpTools = []mcpTool{
+	{
+		Name:        "translate",
+		Description: "Look up a word in a PONS dictionary and return its entry: headwords, senses and translations.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"word": map[string]any{"type": "string", "description": "The word to look up"},
+				"dict": map[string]any{"type": "string", "description": "The dictionary key, e.g. deen for German-English"},
+			},
+			"required": []string{"word", "dict"},
+		},
+	},
+	{
+		Name:        "synonyms",
+		Description: "List synonyms of a word, one per line, e.g. as wrong choices of a quiz.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"word": map[string]any{"type": "string", "description": "The word to find synonyms of"},
+				"lang": map[string]any{"type": "string", "description": "The language code of the word, e.g. de"},
+			},
+			"required": []string{"word", "lang"},
+		},
+	},
+	{
+		Name:        "list_dictionaries",
+		Description: "List the available PONS dictionaries with their keys.",
+		InputSchema: map[string]any{"type": "object", "properties": map[string]any{}},
+	},
+}
+
+func runMCPServer() error {
+	return serveMCP(os.Stdin, os.Stdout)
+}
+
+func serveMCP(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	encoder := json.NewEncoder(w)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var req rpcRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			encoder.Encode(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: -32700, Message: "parse error"}})
+			continue
+		}
+
+		result, rpcErr := handleMCPRequest(req)
+		if req.ID == nil {
+			// Notifications don't get a response
+			continue
+		}
+		if err := encoder.Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}); err != nil {
+			return fmt.Errorf("could not write response: %w", err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+func handleMCPRequest(req rpcRequest) (any, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		return map[string]any{
+			"protocolVersion": mcpProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "pons-cli", "version": version},
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return map[string]any{"tools": mcpTools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string            `json:"name"`
+			Arguments map[string]string `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: -32602, Message: "invalid params"}
+		}
+		return callMCPTool(params.Name, params.Arguments), nil
+	}
+
+	if strings.HasPrefix(req.Method, "notifications/") {
+		return nil, nil
+	}
+	return nil, &rpcError{Code: -32601, Message: "method not found: " + req.Method}
+}
+
+func callMCPTool(name string, args map[string]string) mcpToolResult {
+	text, err := runMCPTool(name, args)
+	if err != nil {
+		return mcpToolResult{Content: []mcpContent{{Type: "text", Text: err.Error()}}, IsError: true}
+	}
+	return mcpToolResult{Content: []mcpContent{{Type: "text", Text: text}}}
+}
+
+func runMCPTool(name string, args map[string]string) (string, error) {
+	switch name {
+	case "translate":
+		word, dict := args["word"], args["dict"]
+		if word == "" || dict == "" {
+			return "", fmt.Errorf("missing word or dict argument")
+		}
+		translations, cached, err := lookupTranslation(word, dict)
+		if err != nil {
+			return "", err
+		}
+		if err := addSearchHistory(word, dict, cached); err != nil {
+			// Log the error, but don't fail the call
+			slog.Warn("could not add search history", "err", err)
+		}
+		var buf bytes.Buffer
+		tableRenderer{}.Render(&buf, translations, dict, word, renderOptions{Width: getTermWidth()})
+		return buf.String(), nil
+	case "synonyms":
+		word, lang := args["word"], args["lang"]
+		if word == "" || lang == "" {
+			return "", fmt.Errorf("missing word or lang argument")
+		}
+		synonyms, err := getSynonyms(word, lang)
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(synonyms, "\n") + "\n", nil
+	case "list_dictionaries":
+		dictionaries, err := getDictionaries()
+		if err != nil {
+			return "", err
+		}
+		var sb strings.Builder
+		for _, dict := range dictionaries {
+			if len(dict.Languages) == 2 {
+				fmt.Fprintf(&sb, "%s: %s\n", dict.Key, dict.SimpleLabel)
+			}
+		}
+		return sb.String(), nil
+	}
+	return "", fmt.Errorf("unknown tool: %s", name)
+}
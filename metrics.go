@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the buckets of the
+// latency histograms.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type histogram struct {
+	counts []int64
+	sum    float64
+	count  int64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	if h.counts == nil {
+		h.counts = make([]int64, len(latencyBuckets))
+	}
+	seconds := d.Seconds()
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// metrics are the counters of the lookups served and of the API requests
+// made, exposed on /metrics by the server for Prometheus.
+type metrics struct {
+	mu             sync.Mutex
+	lookups        map[string]int64
+	cacheHits      int64
+	cacheMisses    int64
+	apiErrors      map[string]int64
+	lookupDuration histogram
+	apiDuration    map[string]*histogram
+}
+
+var serverMetrics = &metrics{
+	lookups:     map[string]int64{},
+	apiErrors:   map[string]int64{},
+	apiDuration: map[string]*histogram{},
+}
+
+// recordLookup counts a lookup served, whose result is "found",
+// "not_found" or "error".
+func (m *metrics) recordLookup(result string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lookups[result]++
+	m.lookupDuration.observe(d)
+}
+
+func (m *metrics) recordCacheHit() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cacheHits++
+}
+
+// recordAPIRequest counts a request made to an API, the cache missing its
+// response. Failed requests have no status.
+func (m *metrics) recordAPIRequest(host string, status int, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cacheMisses++
+	if status == 0 || status >= 400 {
+		m.apiErrors[host]++
+	}
+	if m.apiDuration[host] == nil {
+		m.apiDuration[host] = &histogram{}
+	}
+	m.apiDuration[host].observe(d)
+}
+
+// write writes the metrics in the Prometheus text format.
+func (m *metrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP pons_lookups_total Lookups served, by result.")
+	fmt.Fprintln(w, "# TYPE pons_lookups_total counter")
+	for _, result := range []string{"found", "not_found", "error"} {
+		fmt.Fprintf(w, "pons_lookups_total{result=%s} %d\n", quoteLabel(result), m.lookups[result])
+	}
+
+	fmt.Fprintln(w, "# HELP pons_cache_hits_total Responses served from the cache.")
+	fmt.Fprintln(w, "# TYPE pons_cache_hits_total counter")
+	fmt.Fprintf(w, "pons_cache_hits_total %d\n", m.cacheHits)
+	fmt.Fprintln(w, "# HELP pons_cache_misses_total Responses requested from the APIs, the cache missing them.")
+	fmt.Fprintln(w, "# TYPE pons_cache_misses_total counter")
+	fmt.Fprintf(w, "pons_cache_misses_total %d\n", m.cacheMisses)
+
+	hosts := make([]string, 0, len(m.apiDuration))
+	for host := range m.apiDuration {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	fmt.Fprintln(w, "# HELP pons_api_errors_total Failed API requests, by host.")
+	fmt.Fprintln(w, "# TYPE pons_api_errors_total counter")
+	for _, host := range hosts {
+		fmt.Fprintf(w, "pons_api_errors_total{host=%s} %d\n", quoteLabel(host), m.apiErrors[host])
+	}
+
+	fmt.Fprintln(w, "# HELP pons_lookup_duration_seconds Latency of the lookups served.")
+	fmt.Fprintln(w, "# TYPE pons_lookup_duration_seconds histogram")
+	writeHistogram(w, "pons_lookup_duration_seconds", "", &m.lookupDuration)
+
+	fmt.Fprintln(w, "# HELP pons_api_request_duration_seconds Latency of the API requests, by host.")
+	fmt.Fprintln(w, "# TYPE pons_api_request_duration_seconds histogram")
+	for _, host := range hosts {
+		writeHistogram(w, "pons_api_request_duration_seconds", "host="+quoteLabel(host)+",", m.apiDuration[host])
+	}
+}
+
+// writeHistogram writes the buckets, the sum and the count of a histogram,
+// labels being the other labels of its series followed by a comma.
+func writeHistogram(w io.Writer, name, labels string, h *histogram) {
+	for i, bound := range latencyBuckets {
+		var count int64
+		if h.counts != nil {
+			count = h.counts[i]
+		}
+		fmt.Fprintf(w, "%s_bucket{%sle=\"%g\"} %d\n", name, labels, bound, count)
+	}
+	fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", name, labels, h.count)
+	labels = strings.TrimSuffix(labels, ",")
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	fmt.Fprintf(w, "%s_sum%s %g\n", name, labels, h.sum)
+	fmt.Fprintf(w, "%s_count%s %d\n", name, labels, h.count)
+}
+
+// quoteLabel quotes a label value, escaping what the text format asks for.
+func quoteLabel(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
+
+func handleServeMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	serverMetrics.write(w)
+}
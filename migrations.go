@@ -0,0 +1,173 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// migrations are the steps of the database schema, applied in order to
+// bring the databases of older versions up to date. Once released, a step
+// must not be changed: add a new one instead.
+var migrations = []string{
+	// 1: search history, which predates the migrations, hence IF NOT EXISTS
+	`CREATE TABLE IF NOT EXISTS search_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		searched_term TEXT NOT NULL,
+		dict TEXT NOT NULL,
+		date DATETIME NOT NULL
+	)`,
+	// 2: daily activity, for the streaks and the daily goal
+	`CREATE TABLE activity (
+		day TEXT PRIMARY KEY,
+		lookups INTEGER NOT NULL DEFAULT 0,
+		reviews INTEGER NOT NULL DEFAULT 0
+	)`,
+	// 3: flashcards, scheduled for .review
+	`CREATE TABLE flashcards (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		word TEXT NOT NULL,
+		dict TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		front TEXT NOT NULL,
+		back TEXT NOT NULL,
+		due DATETIME NOT NULL,
+		interval INTEGER NOT NULL DEFAULT 0,
+		ease REAL NOT NULL DEFAULT 2.5,
+		repetitions INTEGER NOT NULL DEFAULT 0,
+		created DATETIME NOT NULL,
+		UNIQUE(dict, kind, front)
+	)`,
+	// 4: review log of the flashcards
+	`CREATE TABLE review_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		card_id INTEGER NOT NULL REFERENCES flashcards(id) ON DELETE CASCADE,
+		date DATETIME NOT NULL,
+		grade INTEGER NOT NULL,
+		interval INTEGER NOT NULL,
+		ease REAL NOT NULL
+	)`,
+	// 5: accuracy of the words typed in .practice
+	`CREATE TABLE practice_stats (
+		word TEXT NOT NULL,
+		dict TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		correct INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY(word, dict)
+	)`,
+	// 6: outcome of the lookups, for .notfound
+	`ALTER TABLE search_history ADD COLUMN found INTEGER NOT NULL DEFAULT 1;
+	ALTER TABLE search_history ADD COLUMN http_status INTEGER`,
+	// 7: words looked up while offline, fetched by .queue flush
+	`CREATE TABLE lookup_queue (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		word TEXT NOT NULL,
+		dict TEXT NOT NULL,
+		date DATETIME NOT NULL,
+		UNIQUE(word, dict)
+	)`,
+	// 8: PONS API requests per month, for monthly_request_budget
+	`CREATE TABLE api_usage (
+		month TEXT PRIMARY KEY,
+		requests INTEGER NOT NULL DEFAULT 0
+	)`,
+	// 9: HTTP requests and cache hits, for .stats network
+	`CREATE TABLE request_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		date DATETIME NOT NULL,
+		host TEXT NOT NULL,
+		cached INTEGER NOT NULL DEFAULT 0,
+		status INTEGER,
+		duration_ms INTEGER,
+		size INTEGER
+	)`,
+	// 10: REPL command history, which was kept in cmd_history.txt before
+	`CREATE TABLE command_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		command TEXT NOT NULL UNIQUE,
+		date DATETIME NOT NULL
+	)`,
+	// 11: translations corrected by the user, for .correct
+	`CREATE TABLE corrections (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		dict TEXT NOT NULL,
+		headword TEXT NOT NULL,
+		correction TEXT NOT NULL,
+		date DATETIME NOT NULL,
+		UNIQUE(dict, headword)
+	)`,
+	// 12: study sessions saved with .session save, as JSON
+	`CREATE TABLE sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		data TEXT NOT NULL,
+		date DATETIME NOT NULL
+	)`,
+	// 13: decks of the flashcards, the default deck being ''
+	`ALTER TABLE flashcards ADD COLUMN deck TEXT NOT NULL DEFAULT ''`,
+	// 14: notes and tags of the words, from the word lists imported, the
+	// tags separated by spaces
+	`ALTER TABLE flashcards ADD COLUMN note TEXT NOT NULL DEFAULT '';
+	ALTER TABLE flashcards ADD COLUMN tags TEXT NOT NULL DEFAULT ''`,
+	// 15: decks of .deck, including the ones the lists were imported to
+	`CREATE TABLE decks (
+		name TEXT PRIMARY KEY,
+		created DATETIME NOT NULL
+	);
+	INSERT INTO decks(name, created)
+		SELECT deck, MIN(created) FROM flashcards WHERE deck != '' GROUP BY deck`,
+}
+
+// migrateDatabase applies the migrations the database lacks, recording the
+// schema version in the schema_version table.
+func migrateDatabase() error {
+	return withLock(func() error {
+		if _, err := db.Exec(`
+			CREATE TABLE IF NOT EXISTS schema_version (
+				version INTEGER NOT NULL,
+				date DATETIME NOT NULL
+			)
+		`); err != nil {
+			return fmt.Errorf("could not create schema_version table: %w", err)
+		}
+
+		version, err := getSchemaVersion()
+		if err != nil {
+			return err
+		}
+		if version > len(migrations) {
+			return fmt.Errorf("database schema version %d is newer than this version of pons-cli supports (%d)", version, len(migrations))
+		}
+
+		for i := version; i < len(migrations); i++ {
+			if err := applyMigration(i+1, migrations[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func getSchemaVersion() (int, error) {
+	var version sql.NullInt64
+	if err := db.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("could not get schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+func applyMigration(version int, migration string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin migration %d: %w", version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(migration); err != nil {
+		return fmt.Errorf("could not apply migration %d: %w", version, err)
+	}
+	if _, err := tx.Exec("INSERT INTO schema_version(version, date) VALUES(?, ?)", version, time.Now()); err != nil {
+		return fmt.Errorf("could not record migration %d: %w", version, err)
+	}
+	return tx.Commit()
+}
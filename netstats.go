@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// requestLogDays is how long requests are kept in the request log.
+const requestLogDays = 90
+
+// instrumentedTransport identifies pons-cli to the APIs, and records the
+// latency and the size of the responses in the request log.
+type instrumentedTransport struct {
+	base http.RoundTripper
+}
+
+func (t instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		// RoundTrip must not modify the request
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", "pons-cli/"+version)
+	}
+
+	if isDebugEnabled() {
+		debugRequest(req)
+	}
+
+	stopSpinner := startSpinner()
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	stopSpinner()
+	if err != nil {
+		if isDebugEnabled() {
+			writeDebugLog("error", []byte(err.Error()))
+		}
+		recordRequest(req.URL.Host, 0, time.Since(start), 0)
+		return nil, err
+	}
+	if isDebugEnabled() {
+		debugResponse(resp, time.Since(start))
+	}
+	resp.Body = &countingBody{ReadCloser: resp.Body, onClose: func(size int64) {
+		recordRequest(req.URL.Host, resp.StatusCode, time.Since(start), size)
+	}}
+	return resp, nil
+}
+
+// countingBody counts the bytes read from a response body, which may be
+// decompressed already, and reports them once closed.
+type countingBody struct {
+	io.ReadCloser
+	size    int64
+	onClose func(size int64)
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	if b.onClose != nil {
+		b.onClose(b.size)
+		b.onClose = nil
+	}
+	return b.ReadCloser.Close()
+}
+
+// recordRequest adds a request to the request log. Failed requests have no
+// status.
+func recordRequest(host string, status int, duration time.Duration, size int64) {
+	serverMetrics.recordAPIRequest(host, status, duration)
+	if db == nil {
+		return
+	}
+	var statusValue any
+	if status != 0 {
+		statusValue = status
+	}
+	_, err := db.Exec("INSERT INTO request_log(date, host, status, duration_ms, size) VALUES(?, ?, ?, ?, ?)",
+		time.Now(), host, statusValue, duration.Milliseconds(), size)
+	if err != nil {
+		// Log the error, but don't fail the request
+		slog.Warn("could not record request", "err", err)
+	}
+}
+
+// recordCacheHit adds a request to rawURL served from the cache to the
+// request log.
+func recordCacheHit(rawURL string) {
+	serverMetrics.recordCacheHit()
+	u, err := url.Parse(rawURL)
+	if err != nil || db == nil {
+		return
+	}
+	if _, err := db.Exec("INSERT INTO request_log(date, host, cached) VALUES(?, ?, 1)", time.Now(), u.Host); err != nil {
+		// Log the error, but don't fail the command
+		slog.Warn("could not record cache hit", "err", err)
+	}
+}
+
+func cleanupRequestLog() error {
+	_, err := db.Exec("DELETE FROM request_log WHERE date < ?", time.Now().AddDate(0, 0, -requestLogDays))
+	if err != nil {
+		return fmt.Errorf("could not clean up request log: %w", err)
+	}
+	return nil
+}
+
+// showNetworkStats shows the response times and the cache hit rate of each
+// API over the request log.
+func showNetworkStats() error {
+	rows, err := db.Query(`
+		SELECT host,
+			SUM(cached = 0),
+			SUM(cached = 1),
+			SUM(cached = 0 AND status IS NULL),
+			COALESCE(AVG(CASE WHEN cached = 0 THEN duration_ms END), 0),
+			COALESCE(AVG(CASE WHEN cached = 0 AND status IS NOT NULL THEN size END), 0)
+		FROM request_log
+		GROUP BY host
+		ORDER BY COUNT(*) DESC
+	`)
+	if err != nil {
+		return fmt.Errorf("could not query request log: %w", err)
+	}
+	defer rows.Close()
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Host", "Requests", "Failed", "Avg Time", "Avg Size", "Cache Hits"})
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 2, Align: text.AlignRight},
+		{Number: 3, Align: text.AlignRight},
+		{Number: 4, Align: text.AlignRight},
+		{Number: 5, Align: text.AlignRight},
+		{Number: 6, Align: text.AlignRight},
+	})
+	for rows.Next() {
+		var host string
+		var requests, hits, failed int
+		var duration, size float64
+		if err := rows.Scan(&host, &requests, &hits, &failed, &duration, &size); err != nil {
+			return fmt.Errorf("could not scan row: %w", err)
+		}
+		t.AppendRow(table.Row{
+			host,
+			requests,
+			failed,
+			fmt.Sprintf("%.0f ms", duration),
+			fmt.Sprintf("%.1f KB", size/1024),
+			fmt.Sprintf("%d%%", 100*hits/(hits+requests)),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	color.New(color.FgGreen).Printf("Last %d days\n", requestLogDays)
+	t.Render()
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// cliticSuffixes are the clitics attached to the end of words, by
+// language, which strip_clitics removes from queries.
+var cliticSuffixes = map[string][]string{
+	"de": {"'s", "’s"},
+	"en": {"'s", "’s", "'ll", "’ll", "'ve", "’ve", "'re", "’re", "'d", "’d"},
+	"fr": {"-t-il", "-t-elle", "-t-on", "-moi", "-toi", "-le", "-la", "-les", "-lui", "-nous", "-vous", "-leur", "-y", "-en"},
+	"pt": {"-me", "-te", "-se", "-lhe", "-lhes", "-o", "-a", "-os", "-as", "-nos", "-vos"},
+}
+
+// normalizeQuery cleans a query pasted from a text up: the punctuation
+// around it is stripped and its whitespace collapsed, then the clitics of
+// the languages of dict are removed if strip_clitics is on.
+func normalizeQuery(query, dict string) string {
+	if !config.NormalizeInput {
+		return query
+	}
+	collapsed := strings.TrimLeftFunc(strings.Join(strings.Fields(query), " "), isStrippedPunct)
+	normalized := strings.TrimRightFunc(collapsed, isStrippedPunct)
+	// Abbreviations like "z.B." keep their last dot
+	if strings.HasPrefix(collapsed[len(normalized):], ".") && strings.Contains(normalized, ".") {
+		normalized += "."
+	}
+
+	if config.StripClitics && len(dict) == 4 {
+		for _, lang := range []string{dict[:2], dict[2:]} {
+			normalized = stripClitics(normalized, lang)
+		}
+	}
+
+	if normalized == "" {
+		// Nothing but punctuation, which may have an entry itself
+		return strings.TrimSpace(query)
+	}
+	return normalized
+}
+
+// isStrippedPunct tells whether r is punctuation stripped around queries.
+// Hyphens are kept, for prefixes and suffixes like "-ung".
+func isStrippedPunct(r rune) bool {
+	return unicode.IsPunct(r) && r != '-'
+}
+
+// stripClitics removes a clitic of lang from the end of word, if what's
+// left is still a word.
+func stripClitics(word, lang string) string {
+	lower := strings.ToLower(word)
+	for _, clitic := range cliticSuffixes[lang] {
+		if strings.HasSuffix(lower, clitic) && len(word) > len(clitic)+1 {
+			return word[:len(word)-len(clitic)]
+		}
+	}
+	return word
+}
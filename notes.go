@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// noteFileName replaces the characters file systems or note apps don't
+// allow in file names.
+var noteFileName = strings.NewReplacer("/", "-", "\\", "-", ":", "-", "*", "-", "?", "-", "\"", "-", "<", "-", ">", "-", "|", "-", "#", "-", "^", "-", "[", "-", "]", "-")
+
+type savedWord struct {
+	Word  string
+	Dict  string
+	Saved time.Time
+}
+
+// getSavedWords returns the words saved with .save, in the order they were
+// saved.
+func getSavedWords() ([]savedWord, error) {
+	rows, err := db.Query(`
+		SELECT word, dict, MIN(created) FROM flashcards
+		WHERE kind = 'word'
+		GROUP BY word, dict
+		ORDER BY MIN(created)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("could not query flashcards: %w", err)
+	}
+	defer rows.Close()
+
+	var words []savedWord
+	for rows.Next() {
+		var word savedWord
+		// MIN() loses the column type, hence the date is scanned as text
+		var saved string
+		if err := rows.Scan(&word.Word, &word.Dict, &saved); err != nil {
+			return nil, fmt.Errorf("could not scan row: %w", err)
+		}
+		word.Saved, _ = time.Parse("2006-01-02 15:04:05.999999999-07:00", saved)
+		words = append(words, word)
+	}
+	return words, rows.Err()
+}
+
+// getNote returns the Markdown note of a saved word: YAML frontmatter
+// followed by its entry rendered as Markdown.
+func getNote(word savedWord, translations TranslationResponse) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	fmt.Fprintf(&buf, "word: %s\n", strconv.Quote(word.Word))
+	fmt.Fprintf(&buf, "dict: %s\n", word.Dict)
+	if len(word.Dict) == 4 {
+		fmt.Fprintf(&buf, "source_lang: %s\n", word.Dict[:2])
+		fmt.Fprintf(&buf, "target_lang: %s\n", word.Dict[2:])
+	}
+	fmt.Fprintf(&buf, "tags: [pons-cli, vocabulary, %s]\n", word.Dict)
+	fmt.Fprintf(&buf, "date: %s\n", word.Saved.In(getLocation()).Format("2006-01-02"))
+	buf.WriteString("---\n\n")
+	fmt.Fprintf(&buf, "# %s\n\n", word.Word)
+	renderTranslationAs(&buf, "md", translations, word.Dict, word.Word)
+	return buf.Bytes()
+}
+
+// exportNotes writes a Markdown note per saved word to dir, for note apps
+// like Obsidian. Notes already in dir are left alone, as they may have
+// been edited.
+func exportNotes(dir string) error {
+	words, err := getSavedWords()
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		return fmt.Errorf("no saved words, use .save to save some")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("could not create notes directory: %w", err)
+	}
+
+	// Words saved in several dictionaries get a note per dictionary
+	dicts := map[string]int{}
+	for _, word := range words {
+		dicts[strings.ToLower(word.Word)]++
+	}
+
+	written, skipped := 0, 0
+	for _, word := range words {
+		name := word.Word
+		if dicts[strings.ToLower(word.Word)] > 1 {
+			name = fmt.Sprintf("%s (%s)", word.Word, word.Dict)
+		}
+		path := filepath.Join(dir, noteFileName.Replace(name)+".md")
+		if _, err := os.Stat(path); err == nil {
+			skipped++
+			continue
+		}
+
+		translations, err := getTranslation(word.Word, word.Dict)
+		if err != nil {
+			// Log the error, but don't fail the command
+			slog.Warn("could not translate", "term", word.Word, "err", err)
+			continue
+		}
+		if err := os.WriteFile(path, getNote(word, translations), 0644); err != nil {
+			return fmt.Errorf("could not write note: %w", err)
+		}
+		written++
+	}
+
+	color.New(color.FgGreen).Printf("%d notes written to %s", written, dir)
+	if skipped > 0 {
+		fmt.Printf(", %d already there", skipped)
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// getLookupStatus returns the HTTP status a failed lookup got, or 0 when
+// the request didn't get a response.
+func getLookupStatus(err error) int {
+	if errors.Is(err, errNoTranslation) {
+		return http.StatusNoContent
+	}
+	return getStatusCode(err)
+}
+
+// recordFailedLookup adds a failed lookup to the search history, to be
+// listed by .notfound.
+func recordFailedLookup(term, dict string, lookupErr error) {
+	var status any
+	if s := getLookupStatus(lookupErr); s != 0 {
+		status = s
+	}
+	_, err := db.Exec("INSERT INTO search_history(searched_term, dict, date, found, http_status) VALUES(?, ?, ?, 0, ?)", term, dict, time.Now(), status)
+	if err != nil {
+		// Log the error, but don't fail the command
+		slog.Warn("could not add search history", "err", err)
+	}
+	logLookupEvent(term, dict, false, false)
+}
+
+type failedLookup struct {
+	Term     string
+	Dict     string
+	Attempts int
+	Status   *int
+	Date     time.Time
+}
+
+// getFailedLookups returns the terms whose lookups failed and didn't
+// succeed since, of dict if not empty.
+func getFailedLookups(dict string) ([]failedLookup, error) {
+	query := `
+		SELECT searched_term, dict, COUNT(*), http_status, date, MAX(date) FROM search_history f
+		WHERE found = 0 AND NOT EXISTS (
+			SELECT 1 FROM search_history s
+			WHERE s.found = 1 AND s.searched_term = f.searched_term AND s.dict = f.dict AND s.date > f.date
+		)`
+	var args []any
+	if dict != "" {
+		query += " AND dict = ?"
+		args = append(args, dict)
+	}
+	rows, err := db.Query(query+" GROUP BY searched_term, dict ORDER BY MAX(date) DESC", args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query search history: %w", err)
+	}
+	defer rows.Close()
+
+	var lookups []failedLookup
+	for rows.Next() {
+		var l failedLookup
+		// MAX() loses the column type, unlike the columns of the row it
+		// picks, hence the date and status of the last attempt are scanned
+		var latest any
+		if err := rows.Scan(&l.Term, &l.Dict, &l.Attempts, &l.Status, &l.Date, &latest); err != nil {
+			return nil, fmt.Errorf("could not scan row: %w", err)
+		}
+		lookups = append(lookups, l)
+	}
+	return lookups, rows.Err()
+}
+
+// clearFailedLookups removes the failed lookups of term from the search
+// history, or all of them if term is empty.
+func clearFailedLookups(term, dict string) (int64, error) {
+	query := "DELETE FROM search_history WHERE found = 0"
+	var args []any
+	if term != "" {
+		query += " AND searched_term = ?"
+		args = append(args, term)
+	}
+	if dict != "" {
+		query += " AND dict = ?"
+		args = append(args, dict)
+	}
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("could not clear failed lookups: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// handleNotFoundCommand lists the failed lookups, retries them or clears
+// them.
+func handleNotFoundCommand(args []string) error {
+	action := ""
+	if len(args) > 0 && (args[0] == "retry" || args[0] == "clear") {
+		action, args = args[0], args[1:]
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("usage: .notfound [retry|clear] [<dict>]")
+	}
+	dict := ""
+	if len(args) == 1 {
+		dict = args[0]
+	}
+
+	switch action {
+	case "clear":
+		n, err := clearFailedLookups("", dict)
+		if err != nil {
+			return err
+		}
+		printNotice("Failed lookups cleared: %d\n", n)
+		return nil
+	case "retry":
+		return retryFailedLookups(dict)
+	}
+
+	lookups, err := getFailedLookups(dict)
+	if err != nil {
+		return err
+	}
+	if len(lookups) == 0 {
+		printNotice("No failed lookups\n")
+		return nil
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Searched Term", "Dictionary", "Attempts", "Status", "Last Attempt"})
+	for _, l := range lookups {
+		status := "network error"
+		if l.Status != nil {
+			status = strconv.Itoa(*l.Status)
+		}
+		t.AppendRow(table.Row{l.Term, l.Dict, l.Attempts, status, l.Date.Format("2006-01-02 15:04:05")})
+	}
+	t.Render()
+	return nil
+}
+
+// retryFailedLookups looks the failed lookups up again, with the spelling
+// variants and compounds fallbacks of regular lookups, and forgets those
+// which succeed.
+func retryFailedLookups(dict string) error {
+	lookups, err := getFailedLookups(dict)
+	if err != nil {
+		return err
+	}
+
+	found := 0
+	for _, l := range lookups {
+		color.New(color.FgYellow, color.Bold).Printf("\n%s (%s)\n", l.Term, l.Dict)
+		if err := lookupWord(l.Term, l.Dict); err != nil {
+			printError(err)
+			continue
+		}
+		found++
+		if _, err := clearFailedLookups(l.Term, l.Dict); err != nil {
+			return err
+		}
+	}
+	printNotice("Found now: %d/%d\n", found, len(lookups))
+	return nil
+}
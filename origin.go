@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/fatih/color"
+)
+
+// getHitOrigin returns where a PONS hit comes from: the PONS editorial
+// dictionary, its translations of phrases, or OpenDict, which users
+// contribute to. Hits of other providers have no origin.
+func getHitOrigin(hit Hit) string {
+	switch {
+	case hit.OpenDict:
+		return "OpenDict"
+	case hit.Type == "entry":
+		return "PONS"
+	case hit.Type == "translation":
+		return "PONS translation"
+	}
+	return ""
+}
+
+// formatOrigin returns the origin shown after a hit when show_origin is on.
+func formatOrigin(origin string) string {
+	if !config.ShowOrigin || origin == "" {
+		return ""
+	}
+	c := color.New(color.Faint)
+	if origin == "OpenDict" {
+		// Community entries are less reliable
+		c = color.New(color.FgMagenta)
+	}
+	return c.Sprintf(" [%s]", origin)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+)
+
+// portableDirName is the directory next to the binary holding the config,
+// cache and data in portable mode. Portable mode is enabled whenever it
+// exists, so that a copy on a USB stick needs no flag.
+const portableDirName = "pons-cli-data"
+
+// portableDir holds the config, cache and data of pons-cli instead of the
+// XDG directories when not empty.
+var portableDir string
+
+// configPath overrides the path of the config file of the default profile.
+var configPath string
+
+// getDefaultPortableDir returns the portable directory next to the binary.
+func getDefaultPortableDir() (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(executable), portableDirName), nil
+}
+
+// detectPortableDir enables portable mode when the portable directory
+// exists next to the binary.
+func detectPortableDir() {
+	dir, err := getDefaultPortableDir()
+	if err != nil {
+		return
+	}
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		portableDir = dir
+	}
+}
+
+// resolveBaseDir returns an XDG base directory, or a fallback when it
+// resolves oddly, e.g. to a relative path when HOME or APPDATA isn't set.
+// The last resort is the portable directory next to the binary.
+func resolveBaseDir(dir string, fallback func() (string, error), kind string) string {
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	if dir, err := fallback(); err == nil && filepath.IsAbs(dir) {
+		return dir
+	}
+	if dir, err := getDefaultPortableDir(); err == nil {
+		return filepath.Join(dir, kind)
+	}
+	return filepath.Join(os.TempDir(), kind)
+}
+
+func getAppConfigDir() string {
+	if portableDir != "" {
+		return filepath.Join(portableDir, "config")
+	}
+	return filepath.Join(resolveBaseDir(xdg.ConfigHome, os.UserConfigDir, "config"), "pons-cli")
+}
+
+func getAppCacheBaseDir() string {
+	if portableDir != "" {
+		return filepath.Join(portableDir, "cache")
+	}
+	return filepath.Join(resolveBaseDir(xdg.CacheHome, os.UserCacheDir, "cache"), "pons-cli")
+}
+
+func getAppDataDir() string {
+	if portableDir != "" {
+		return filepath.Join(portableDir, "data")
+	}
+	return filepath.Join(resolveBaseDir(xdg.DataHome, os.UserConfigDir, "data"), "pons-cli")
+}
+
+// getAppStateDir returns the directory of the state files, such as the
+// command history and the session transcripts, which follow the XDG split
+// between state and data: the data directory keeps the database.
+func getAppStateDir() string {
+	if portableDir != "" {
+		return filepath.Join(portableDir, "state")
+	}
+	return filepath.Join(resolveBaseDir(xdg.StateHome, os.UserConfigDir, "state"), "pons-cli")
+}
+
+func getStateFile(name string) (string, error) {
+	return filepath.Join(getAppStateDir(), name), nil
+}
+
+// migrateToStateDir moves a file which used to be kept in the data
+// directory to the state directory.
+func migrateToStateDir(name string) error {
+	oldFile := filepath.Join(getAppDataDir(), name)
+	newFile := filepath.Join(getAppStateDir(), name)
+	if _, err := os.Stat(oldFile); os.IsNotExist(err) {
+		return nil
+	}
+	if _, err := os.Stat(newFile); err == nil {
+		return nil
+	}
+	if err := os.Rename(oldFile, newFile); err != nil {
+		return fmt.Errorf("could not move %s to %s: %w", oldFile, newFile, err)
+	}
+	return nil
+}
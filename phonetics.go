@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/fatih/color"
+	"golang.org/x/net/html"
+)
+
+// getPhonetics returns the IPA transcription PONS gives in the
+// phonetics span of the full headword of an entry, without its brackets.
+func getPhonetics(rom Rom) string {
+	doc, err := html.Parse(strings.NewReader(rom.HeadwordFull))
+	if err != nil {
+		return ""
+	}
+	var phonetics []string
+	var find func(*html.Node)
+	find = func(n *html.Node) {
+		if n.Type == html.ElementNode && hasClass(n, "phonetics") {
+			text := strings.Trim(strings.TrimSpace(nodeText(n)), "[]/")
+			if text = strings.Join(strings.Fields(text), " "); text != "" {
+				phonetics = append(phonetics, text)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			find(c)
+		}
+	}
+	find(doc)
+	return strings.Join(phonetics, ", ")
+}
+
+// formatPhonetics returns the transcription shown after the headword of an
+// entry when phonetics is on.
+func formatPhonetics(rom Rom) string {
+	if !config.Phonetics {
+		return ""
+	}
+	phonetics := getPhonetics(rom)
+	if phonetics == "" {
+		return ""
+	}
+	return color.New(color.FgMagenta).Sprintf(" /%s/", phonetics)
+}
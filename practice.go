@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/chzyer/readline"
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// defaultPracticeWords is the number of words of a .practice session.
+const defaultPracticeWords = 10
+
+// maxNearMissDistance is the number of wrong letters an answer may have to
+// count as a near-miss.
+const maxNearMissDistance = 2
+
+// replReadline is the line editor of the REPL, which commands asking for
+// input share with it. It's nil outside of the REPL.
+var replReadline *readline.Instance
+
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// readAnswer reads a line typed by the user, which isn't added to the
+// command history.
+func readAnswer(prompt string) (string, error) {
+	if replReadline == nil {
+		fmt.Print(prompt)
+		line, err := stdinReader.ReadString('\n')
+		return strings.TrimSpace(line), err
+	}
+	replReadline.SetPrompt(prompt)
+	line, err := replReadline.Readline()
+	return strings.TrimSpace(line), err
+}
+
+// getExpectedAnswers returns the translations of the back of a word card.
+func getExpectedAnswers(back string) []string {
+	// Cards saved by older versions may have targets spanning several lines
+	var targets []string
+	for _, line := range strings.Split(back, "\n") {
+		if _, target, ok := strings.Cut(line, " — "); ok {
+			targets = append(targets, target)
+		} else if len(targets) > 0 {
+			targets[len(targets)-1] += " " + line
+		}
+	}
+
+	var answers []string
+	for _, target := range targets {
+		for _, answer := range strings.FieldsFunc(target, func(r rune) bool { return r == ',' || r == ';' }) {
+			if answer = strings.Join(strings.Fields(answer), " "); answer != "" {
+				answers = append(answers, answer)
+			}
+		}
+	}
+	return answers
+}
+
+// foldAnswer folds case, accents and German letters, so that near-misses
+// can be told apart from wrong answers.
+func foldAnswer(answer string) string {
+	answer = strings.ToLower(strings.Join(strings.Fields(answer), " "))
+	for _, transcription := range germanTranscriptions {
+		answer = strings.ReplaceAll(answer, transcription[1], transcription[0])
+	}
+	return stripAccents(answer)
+}
+
+// levenshtein returns the edit distance between a and b, along with which
+// runes of b are kept in the cheapest edit.
+func levenshtein(a, b []rune) (int, []bool) {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+		}
+	}
+
+	kept := make([]bool, len(b))
+	for i, j := len(a), len(b); i > 0 && j > 0; {
+		switch {
+		case a[i-1] == b[j-1] && d[i][j] == d[i-1][j-1]:
+			kept[j-1] = true
+			i, j = i-1, j-1
+		case d[i][j] == d[i-1][j-1]+1:
+			i, j = i-1, j-1
+		case d[i][j] == d[i-1][j]+1:
+			i--
+		default:
+			j--
+		}
+	}
+	return d[len(a)][len(b)], kept
+}
+
+// highlightDiff renders expected with the letters the answer got wrong
+// highlighted.
+func highlightDiff(answer, expected string) string {
+	_, kept := levenshtein([]rune(answer), []rune(expected))
+	right := color.New(color.FgGreen)
+	wrong := color.New(color.FgRed, color.Bold, color.Underline)
+	var sb strings.Builder
+	for i, r := range []rune(expected) {
+		if kept[i] {
+			sb.WriteString(right.Sprint(string(r)))
+		} else {
+			sb.WriteString(wrong.Sprint(string(r)))
+		}
+	}
+	return sb.String()
+}
+
+// checkAnswer tells whether answer is one of the expected ones. Otherwise,
+// it returns the closest expected answer if the answer nearly matches it.
+func checkAnswer(answer string, expected []string) (bool, string) {
+	nearMiss := ""
+	bestDistance := maxNearMissDistance + 1
+	for _, e := range expected {
+		if strings.EqualFold(strings.Join(strings.Fields(answer), " "), e) {
+			return true, ""
+		}
+		if foldAnswer(answer) == foldAnswer(e) {
+			// Only accents or German letters are wrong
+			nearMiss, bestDistance = e, 0
+			continue
+		}
+		if distance, _ := levenshtein([]rune(strings.ToLower(answer)), []rune(strings.ToLower(e))); distance < bestDistance {
+			nearMiss, bestDistance = e, distance
+		}
+	}
+	return false, nearMiss
+}
+
+func recordPractice(card flashcard, correct bool) error {
+	score := 0
+	if correct {
+		score = 1
+	}
+	_, err := db.Exec(`
+		INSERT INTO practice_stats(word, dict, attempts, correct) VALUES(?, ?, 1, ?)
+		ON CONFLICT(word, dict) DO UPDATE SET attempts = attempts + 1, correct = correct + excluded.correct
+	`, card.Word, card.Dict, score)
+	if err != nil {
+		return fmt.Errorf("could not record practice: %w", err)
+	}
+	return nil
+}
+
+// getPracticeCards returns n random word cards, of dict if not empty.
+func getPracticeCards(dict string, n int) ([]flashcard, error) {
+	query := "SELECT id, word, dict, back FROM flashcards WHERE kind = 'word'"
+	var args []any
+	if dict != "" {
+		query += " AND dict = ?"
+		args = append(args, dict)
+	}
+	rows, err := db.Query(query+" ORDER BY RANDOM() LIMIT ?", append(args, n)...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query flashcards: %w", err)
+	}
+	defer rows.Close()
+
+	var cards []flashcard
+	for rows.Next() {
+		var c flashcard
+		if err := rows.Scan(&c.ID, &c.Word, &c.Dict, &c.Back); err != nil {
+			return nil, fmt.Errorf("could not scan row: %w", err)
+		}
+		cards = append(cards, c)
+	}
+	return cards, rows.Err()
+}
+
+// handlePracticeCommand asks for the translation of saved words, one after
+// the other.
+func handlePracticeCommand(args []string) error {
+	if len(args) == 1 && args[0] == "stats" {
+		return showPracticeStats()
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("usage: .practice [<n>|stats]")
+	}
+	n := defaultPracticeWords
+	if len(args) == 1 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+			return fmt.Errorf("invalid number of words: %s", args[0])
+		}
+	}
+
+	cards, err := getPracticeCards(currentDict, n)
+	if err != nil {
+		return err
+	}
+	if len(cards) == 0 {
+		return fmt.Errorf("no saved words to practice, save some with .save")
+	}
+
+	color.New(color.FgYellow).Println("Type the translation of each word, or an empty line to stop.")
+	correct, asked := 0, 0
+	for i, card := range cards {
+		expected := getExpectedAnswers(card.Back)
+		if len(expected) == 0 {
+			continue
+		}
+
+		color.New(color.FgYellow, color.Bold).Printf("\n[%d/%d] %s\n", i+1, len(cards), card.Word)
+		answer, err := readAnswer("> ")
+		if err != nil {
+			return err
+		}
+		if answer == "" {
+			break
+		}
+		asked++
+
+		ok, nearMiss := checkAnswer(answer, expected)
+		switch {
+		case ok:
+			correct++
+			color.New(color.FgGreen).Println("Correct!")
+		case nearMiss != "":
+			color.New(color.FgYellow).Print("Almost: ")
+			fmt.Println(highlightDiff(answer, nearMiss))
+		default:
+			color.New(color.FgRed).Print("Wrong, expected: ")
+			fmt.Println(strings.Join(expected, ", "))
+		}
+
+		if err := recordPractice(card, ok); err != nil {
+			return err
+		}
+	}
+
+	if asked > 0 {
+		color.New(color.FgGreen).Printf("\n%d/%d correct (%d%%)\n", correct, asked, 100*correct/asked)
+	}
+	return nil
+}
+
+// showPracticeStats shows the accuracy of the practiced words, the least
+// known first.
+func showPracticeStats() error {
+	rows, err := db.Query(`
+		SELECT word, dict, attempts, correct FROM practice_stats
+		ORDER BY CAST(correct AS REAL) / attempts ASC, attempts DESC
+	`)
+	if err != nil {
+		return fmt.Errorf("could not query practice stats: %w", err)
+	}
+	defer rows.Close()
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Word", "Dictionary", "Attempts", "Accuracy"})
+	for rows.Next() {
+		var word, dict string
+		var attempts, correct int
+		if err := rows.Scan(&word, &dict, &attempts, &correct); err != nil {
+			return fmt.Errorf("could not scan row: %w", err)
+		}
+		t.AppendRow(table.Row{word, dict, attempts, fmt.Sprintf("%d%%", 100*correct/attempts)})
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	t.Render()
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// currentProfile is the name of the configuration profile in use, or "" for
+// the default one. Each profile has its own config file and cache directory.
+var currentProfile string
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func checkProfileName(name string) error {
+	if name != "" && !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name: %s", name)
+	}
+	return nil
+}
+
+func getProfilesDir() string {
+	return filepath.Join(getAppConfigDir(), "profiles")
+}
+
+// getConfigFile returns the config file of the current profile.
+func getConfigFile() string {
+	if currentProfile == "" {
+		if configPath != "" {
+			return configPath
+		}
+		return filepath.Join(getAppConfigDir(), "config.toml")
+	}
+	return filepath.Join(getProfilesDir(), currentProfile+".toml")
+}
+
+// getAppCacheDir returns the cache directory of the current profile.
+func getAppCacheDir() string {
+	if currentProfile == "" {
+		return getAppCacheBaseDir()
+	}
+	return filepath.Join(getAppCacheBaseDir(), "profiles", currentProfile)
+}
+
+// getProfiles returns the names of the profiles, but the default one.
+func getProfiles() ([]string, error) {
+	entries, err := os.ReadDir(getProfilesDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read profiles directory: %w", err)
+	}
+
+	var profiles []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".toml"); ok && !entry.IsDir() {
+			profiles = append(profiles, name)
+		}
+	}
+	return profiles, nil
+}
+
+// switchProfile loads the configuration of a profile, which is created with
+// the default settings if it doesn't exist yet.
+func switchProfile(name string) error {
+	if err := checkProfileName(name); err != nil {
+		return err
+	}
+
+	previousProfile, previousConfig := currentProfile, config
+	currentProfile, config = name, Config{}
+	if err := setupConfig(); err != nil {
+		currentProfile, config = previousProfile, previousConfig
+		return err
+	}
+	if err := setupCache(); err != nil {
+		return err
+	}
+
+	if config.DefaultDict != "" {
+		setCurrentDict(config.DefaultDict)
+	}
+	return nil
+}
+
+func handleProfileCommand(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: .profile [<name>]")
+	}
+
+	if len(args) == 1 {
+		name := args[0]
+		if name == "default" {
+			name = ""
+		}
+		return switchProfile(name)
+	}
+
+	profiles, err := getProfiles()
+	if err != nil {
+		return err
+	}
+	for _, profile := range append([]string{"default"}, profiles...) {
+		if profile == currentProfile || (profile == "default" && currentProfile == "") {
+			color.New(color.FgGreen).Printf("* %s\n", profile)
+		} else {
+			fmt.Printf("  %s\n", profile)
+		}
+	}
+	return nil
+}
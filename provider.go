@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/fatih/color"
+)
+
+// Provider is a dictionary backend. Lookups are returned in the PONS format,
+// which is what the rest of the application renders and stores.
+type Provider interface {
+	// Lookup returns the entries of word in the dictionary dict, and whether
+	// they were served from the cache.
+	Lookup(word, dict string) (TranslationResponse, bool, error)
+	// Dictionaries returns the dictionaries the provider can look words up in.
+	Dictionaries() ([]Dictionary, error)
+}
+
+var providers = map[string]Provider{}
+
+func registerProvider(name string, provider Provider) {
+	providers[name] = provider
+}
+
+func init() {
+	registerProvider("pons", ponsProvider{})
+	registerProvider("wiktionary", wiktionaryProvider{})
+}
+
+func getProvider() (Provider, error) {
+	provider, ok := providers[config.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider: %s", config.Provider)
+	}
+	return provider, nil
+}
+
+func getProviderNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func handleProviderCommand(args []string) error {
+	if len(args) == 0 {
+		color.New(color.FgYellow).Println("Usage: .provider <name>")
+		for _, name := range getProviderNames() {
+			if name == config.Provider {
+				color.New(color.FgGreen).Printf("%s", name)
+				fmt.Println(" (current)")
+			} else {
+				color.New(color.FgGreen).Println(name)
+			}
+		}
+		return nil
+	}
+
+	if len(args) != 1 {
+		return fmt.Errorf("invalid number of arguments")
+	}
+
+	if _, ok := providers[args[0]]; !ok {
+		return fmt.Errorf("unknown provider: %s", args[0])
+	}
+	config.Provider = args[0]
+
+	return writeConfig()
+}
+
+// ponsProvider looks words up with the PONS API.
+type ponsProvider struct{}
+
+func (ponsProvider) Lookup(word, dict string) (TranslationResponse, bool, error) {
+	return getPONSTranslation(word, dict)
+}
+
+func (ponsProvider) Dictionaries() ([]Dictionary, error) {
+	return getPONSDictionaries()
+}
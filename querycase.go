@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// queryCases are the ways the case of queries can be handled: sent as
+// typed, lowercased, or retried capitalized when they miss, for German
+// nouns.
+var queryCases = []string{"typed", "lower", "auto"}
+
+// lookupOptions are the settings of a lookup which single queries can
+// override.
+type lookupOptions struct {
+	// Exact keeps only the entries of the query itself
+	Exact bool
+	// Case is how the case of the query is handled, one of queryCases
+	Case string
+}
+
+// getLookupOptions returns the lookup options of the configuration.
+func getLookupOptions() lookupOptions {
+	return lookupOptions{Exact: config.Exact, Case: config.QueryCase}
+}
+
+// applyQueryCase returns the query sent for word.
+func applyQueryCase(word, queryCase string) string {
+	if queryCase == "lower" {
+		return strings.ToLower(word)
+	}
+	return word
+}
+
+// handleCaseCommand looks a word up with a case handling other than
+// query_case.
+func handleCaseCommand(args []string) error {
+	if len(args) < 2 || !slices.Contains(queryCases, args[0]) {
+		return fmt.Errorf("usage: .case %s <word>", strings.Join(queryCases, "|"))
+	}
+	opts := getLookupOptions()
+	opts.Case = args[0]
+	return handleTranslation(strings.Join(args[1:], " "), opts)
+}
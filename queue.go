@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+type queuedLookup struct {
+	Word string
+	Dict string
+	Date time.Time
+}
+
+// queueLookup queues a lookup which failed because the network is
+// unreachable, to be fetched by .queue flush. It returns the lookup error,
+// completed when the lookup was queued.
+func queueLookup(word, dict string, lookupErr error) error {
+	var urlErr *url.Error
+	if !errors.As(lookupErr, &urlErr) || usingFixtures() {
+		return lookupErr
+	}
+	_, err := db.Exec("INSERT INTO lookup_queue(word, dict, date) VALUES(?, ?, ?) ON CONFLICT(word, dict) DO NOTHING", word, dict, time.Now())
+	if err != nil {
+		// Log the error, but don't fail the command
+		slog.Warn("could not queue lookup", "err", err)
+		return lookupErr
+	}
+	return fmt.Errorf("%w (queued, fetch it later with .queue flush)", lookupErr)
+}
+
+func getQueuedLookups() ([]queuedLookup, error) {
+	rows, err := db.Query("SELECT word, dict, date FROM lookup_queue ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("could not query lookup queue: %w", err)
+	}
+	defer rows.Close()
+
+	var lookups []queuedLookup
+	for rows.Next() {
+		var l queuedLookup
+		if err := rows.Scan(&l.Word, &l.Dict, &l.Date); err != nil {
+			return nil, fmt.Errorf("could not scan row: %w", err)
+		}
+		lookups = append(lookups, l)
+	}
+	return lookups, rows.Err()
+}
+
+// flushQueue fetches the queued lookups, which caches them, and removes
+// them from the queue. It stops at the first network error, as the network
+// is still unreachable then.
+func flushQueue() (fetched int, remaining int, err error) {
+	lookups, err := getQueuedLookups()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for i, l := range lookups {
+		_, cached, lookupErr := lookupTranslation(l.Word, l.Dict)
+		var urlErr *url.Error
+		if errors.As(lookupErr, &urlErr) {
+			return fetched, len(lookups) - i, nil
+		}
+		if lookupErr != nil {
+			recordFailedLookup(l.Word, l.Dict, lookupErr)
+		} else {
+			fetched++
+			if err := addSearchHistory(l.Word, l.Dict, cached); err != nil {
+				// Log the error, but don't fail the command
+				slog.Warn("could not add search history", "err", err)
+			}
+		}
+		if _, err := db.Exec("DELETE FROM lookup_queue WHERE word = ? AND dict = ?", l.Word, l.Dict); err != nil {
+			return fetched, len(lookups) - i, fmt.Errorf("could not remove queued lookup: %w", err)
+		}
+	}
+	return fetched, 0, nil
+}
+
+// autoFlushQueue flushes the queue after a successful lookup, which tells
+// the network may be back.
+func autoFlushQueue() {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM lookup_queue").Scan(&count); err != nil || count == 0 {
+		return
+	}
+	fetched, _, err := flushQueue()
+	if err != nil {
+		// Log the error, but don't fail the command
+		slog.Warn("could not flush lookup queue", "err", err)
+	}
+	if fetched > 0 {
+		printNotice("Fetched %d queued lookups, see them with .history\n", fetched)
+	}
+}
+
+// handleQueueCommand lists, fetches or clears the lookups queued while
+// offline.
+func handleQueueCommand(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: .queue [flush|clear]")
+	}
+	if len(args) == 0 {
+		lookups, err := getQueuedLookups()
+		if err != nil {
+			return err
+		}
+		if len(lookups) == 0 {
+			printNotice("No queued lookups\n")
+			return nil
+		}
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Word", "Dictionary", "Date"})
+		for _, l := range lookups {
+			t.AppendRow(table.Row{l.Word, l.Dict, l.Date.Format("2006-01-02 15:04:05")})
+		}
+		t.Render()
+		return nil
+	}
+
+	switch args[0] {
+	case "flush":
+		fetched, remaining, err := flushQueue()
+		if err != nil {
+			return err
+		}
+		printNotice("Fetched: %d\n", fetched)
+		if remaining > 0 {
+			return fmt.Errorf("network still unreachable, %d lookups left in the queue", remaining)
+		}
+		return nil
+	case "clear":
+		if _, err := db.Exec("DELETE FROM lookup_queue"); err != nil {
+			return fmt.Errorf("could not clear lookup queue: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("usage: .queue [flush|clear]")
+}
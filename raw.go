@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// htmlTag matches the tags of the HTML snippets inside JSON strings.
+var htmlTag = regexp.MustCompile(`<[^<>]+>`)
+
+// getRawResponse returns the JSON response of the last lookup, as cached,
+// and whether it's the raw one. Results of other providers than PONS, or
+// made of several responses like compounds, are encoded again.
+func getRawResponse(result *lookupResult) ([]byte, bool, error) {
+	if config.Provider == "pons" {
+		if cacheFile, err := getPONSCacheFile(result.Word, result.Dict); err == nil {
+			if body, err := readCacheFile(cacheFile); err == nil {
+				return body, true, nil
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	// The HTML snippets are easier to read unescaped
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(result.Translations); err != nil {
+		return nil, false, fmt.Errorf("could not encode result: %w", err)
+	}
+	return buf.Bytes(), false, nil
+}
+
+// highlightJSON colors the keys, the values and the HTML tags inside the
+// strings of indented JSON.
+func highlightJSON(indented []byte) string {
+	keyColor := color.New(color.FgCyan)
+	stringColor := color.New(color.FgGreen)
+	tagColor := color.New(color.FgBlue)
+	literalColor := color.New(color.FgMagenta)
+	numberColor := color.New(color.FgYellow)
+
+	var sb strings.Builder
+	s := string(indented)
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			str := s[i:min(end+1, len(s))]
+			i = end + 1
+			if strings.HasPrefix(strings.TrimLeft(s[min(i, len(s)):], " "), ":") {
+				sb.WriteString(keyColor.Sprint(str))
+				continue
+			}
+			last := 0
+			for _, tag := range htmlTag.FindAllStringIndex(str, -1) {
+				sb.WriteString(stringColor.Sprint(str[last:tag[0]]))
+				sb.WriteString(tagColor.Sprint(str[tag[0]:tag[1]]))
+				last = tag[1]
+			}
+			sb.WriteString(stringColor.Sprint(str[last:]))
+		case c == 't' || c == 'f' || c == 'n':
+			end := i
+			for end < len(s) && s[end] >= 'a' && s[end] <= 'z' {
+				end++
+			}
+			sb.WriteString(literalColor.Sprint(s[i:end]))
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i
+			for end < len(s) && strings.IndexByte("+-.0123456789eE", s[end]) >= 0 {
+				end++
+			}
+			sb.WriteString(numberColor.Sprint(s[i:end]))
+			i = end
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// handleRawCommand prints the JSON response of the last lookup.
+func handleRawCommand() error {
+	result := getLastResult()
+	if result == nil {
+		return fmt.Errorf("no previous lookup")
+	}
+
+	body, raw, err := getRawResponse(result)
+	if err != nil {
+		return err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, bytes.TrimSpace(body), "", "  "); err != nil {
+		return fmt.Errorf("could not indent response: %w", err)
+	}
+
+	if !raw {
+		printNotice("No raw response for %s, showing the result encoded again\n", result.Word)
+	}
+	fmt.Fprintln(color.Output, highlightJSON(indented.Bytes()))
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// runRemind sends a desktop notification when flashcards are due, once, as
+// a systemd timer or cron would run it, or checking again at each interval
+// with --daemon.
+func runRemind(args []string) error {
+	flags := flag.NewFlagSet("remind", flag.ExitOnError)
+	daemon := flags.Bool("daemon", false, "keep running, checking the due flashcards at each interval")
+	interval := flags.Duration("interval", 30*time.Minute, "how often the daemon checks the due flashcards")
+	flags.Parse(args)
+
+	if flags.NArg() != 0 || *interval <= 0 {
+		return fmt.Errorf("usage: pons-cli remind [--daemon [--interval <duration>]]")
+	}
+
+	if !*daemon {
+		_, err := remindDueFlashcards(0)
+		return err
+	}
+
+	color.New(color.FgYellow).Printf("Checking the due flashcards every %s\n", *interval)
+	notified := 0
+	for {
+		var err error
+		if notified, err = remindDueFlashcards(notified); err != nil {
+			// Log the error, but keep running
+			slog.Warn("could not check due flashcards", "err", err)
+		}
+		time.Sleep(*interval)
+	}
+}
+
+// remindDueFlashcards notifies the number of due flashcards, unless it's
+// the number notified last. It returns the number of due flashcards.
+func remindDueFlashcards(notified int) (int, error) {
+	_, due, err := countFlashcards(time.Now())
+	if err != nil {
+		return notified, err
+	}
+	if due == 0 || due == notified {
+		return due, nil
+	}
+
+	message := fmt.Sprintf("%d flashcards are due for review", due)
+	if due == 1 {
+		message = "1 flashcard is due for review"
+	}
+	printNotice("%s\n", message)
+	if err := notifyDesktop("pons-cli", message); err != nil {
+		return due, err
+	}
+	return due, nil
+}
+
+// notifyDesktop shows a desktop notification with the notifier of the
+// system: notify-send on Linux and BSDs, osascript on macOS and a balloon
+// tip from PowerShell on Windows.
+func notifyDesktop(title, message string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", message, title)
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	case "windows":
+		quote := func(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" }
+		script := "Add-Type -AssemblyName System.Windows.Forms; " +
+			"$n = New-Object System.Windows.Forms.NotifyIcon; " +
+			"$n.Icon = [System.Drawing.SystemIcons]::Information; $n.Visible = $true; " +
+			fmt.Sprintf("$n.ShowBalloonTip(10000, %s, %s, 'Info'); Start-Sleep -Seconds 5; $n.Dispose()", quote(title), quote(message))
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", script)
+	default:
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name", title, title, message)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if output := strings.TrimSpace(string(output)); output != "" {
+			err = fmt.Errorf("%w: %s", err, output)
+		}
+		return fmt.Errorf("could not send notification: %w", err)
+	}
+	return nil
+}
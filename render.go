@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"golang.org/x/net/html"
+)
+
+// outputFormat is the format lookup results are rendered in. The oneline
+// format prints one "source → target" pair per line, without colors nor
+// tables, for piping into launchers like rofi or dmenu.
+var outputFormat = "table"
+
+// outputFormats are the formats a lookup result can be rendered in.
+var outputFormats = []string{"table", "oneline", "json", "md", "plain"}
+
+// renderOptions are the settings of the terminal results are rendered for.
+type renderOptions struct {
+	// Width is the number of columns the tables fit in, below
+	// narrowTermWidth rows being stacked instead.
+	Width int
+	// Color tells whether colors are kept, as far as the terminal and
+	// NO_COLOR allow them.
+	Color bool
+}
+
+// getRenderOptions returns the render options of the terminal.
+func getRenderOptions() renderOptions {
+	return renderOptions{Width: getTermWidth(), Color: !color.NoColor}
+}
+
+// writer returns w, dropping the colors written to it unless they are kept.
+func (opts renderOptions) writer(w io.Writer) io.Writer {
+	if opts.Color {
+		return w
+	}
+	return noColorWriter{w}
+}
+
+// noColorWriter drops the color escape sequences written to it, which the
+// color package writes whole.
+type noColorWriter struct {
+	w io.Writer
+}
+
+func (nw noColorWriter) Write(p []byte) (int, error) {
+	if _, err := nw.w.Write(ansiEscape.ReplaceAll(p, nil)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Renderer renders lookup results in an output format.
+type Renderer interface {
+	Render(w io.Writer, translations TranslationResponse, dict string, word string, opts renderOptions)
+}
+
+type tableRenderer struct{}
+
+func (tableRenderer) Render(w io.Writer, translations TranslationResponse, dict string, word string, opts renderOptions) {
+	displayTranslation(opts.writer(w), translations, dict, word, opts.Width)
+}
+
+type onelineRenderer struct{}
+
+func (onelineRenderer) Render(w io.Writer, translations TranslationResponse, dict string, word string, opts renderOptions) {
+	displayOneline(opts.writer(w), translations)
+}
+
+type jsonRenderer struct{}
+
+func (jsonRenderer) Render(w io.Writer, translations TranslationResponse, dict string, word string, opts renderOptions) {
+	displayJSON(w, translations)
+}
+
+type markdownRenderer struct{}
+
+func (markdownRenderer) Render(w io.Writer, translations TranslationResponse, dict string, word string, opts renderOptions) {
+	displayMarkdown(w, translations, dict)
+}
+
+type plainRenderer struct{}
+
+func (plainRenderer) Render(w io.Writer, translations TranslationResponse, dict string, word string, opts renderOptions) {
+	displayPlain(w, translations, dict)
+}
+
+// renderers are the renderers of the output formats.
+var renderers = map[string]Renderer{
+	"table":   tableRenderer{},
+	"oneline": onelineRenderer{},
+	"json":    jsonRenderer{},
+	"md":      markdownRenderer{},
+	"plain":   plainRenderer{},
+	// Not an output format, but the table one in accessible mode
+	"accessible": accessibleRenderer{},
+}
+
+// getRenderer returns the renderer of format, tables by default.
+func getRenderer(format string) Renderer {
+	if renderer, ok := renderers[format]; ok {
+		return renderer
+	}
+	return tableRenderer{}
+}
+
+// renderTranslation renders a lookup result in the selected output format.
+func renderTranslation(w io.Writer, translations TranslationResponse, dict string, word string) {
+	renderTranslationAs(w, outputFormat, translations, dict, word)
+}
+
+func renderTranslationAs(w io.Writer, format string, translations TranslationResponse, dict string, word string) {
+	translations = sortTranslations(translations)
+	if !config.ShowExamples {
+		translations = selectExamples(translations, false)
+	}
+	if config.Accessible && format == "table" {
+		format = "accessible"
+	}
+	getRenderer(format).Render(w, translations, dict, word, getRenderOptions())
+}
+
+func displayOneline(w io.Writer, translations TranslationResponse) {
+	for _, lang := range translations {
+		for _, hit := range lang.Hits {
+			if len(hit.Roms) > 0 {
+				for _, rom := range hit.Roms {
+					for _, arab := range rom.Arabs {
+						for _, translation := range arab.Translations {
+							fmt.Fprintf(w, "%s → %s\n", parseHTML(translation.Source), parseHTML(translation.Target))
+						}
+					}
+				}
+			} else {
+				fmt.Fprintf(w, "%s → %s\n", parseHTML(hit.Source), parseHTML(hit.Target))
+			}
+		}
+	}
+}
+
+func displayJSON(w io.Writer, translations TranslationResponse) {
+	body, err := json.MarshalIndent(translations, "", "  ")
+	if err != nil {
+		fmt.Fprintln(w, "could not marshal json:", err)
+		return
+	}
+	fmt.Fprintln(w, string(body))
+}
+
+// displayMarkdown renders each header as a Markdown table, to be pasted in
+// notes.
+func displayMarkdown(w io.Writer, translations TranslationResponse, dict string) {
+	cell := func(s string) string {
+		s = strings.TrimSpace(plainText(s))
+		s = strings.ReplaceAll(s, "|", "\\|")
+		return strings.ReplaceAll(s, "\n", "<br>")
+	}
+	table := func(rows []Translation, langs [2]string) {
+		fmt.Fprintf(w, "| %s | %s |\n|---|---|\n", strings.ToUpper(langs[0]), strings.ToUpper(langs[1]))
+		for _, row := range rows {
+			fmt.Fprintf(w, "| %s | %s |\n", cell(row.Source), cell(row.Target))
+		}
+		fmt.Fprintln(w)
+	}
+
+	for _, lang := range translations {
+		langs := [2]string{lang.Lang, getTargetLang(dict, lang.Lang)}
+		fmt.Fprintf(w, "## %s > %s\n\n", strings.ToUpper(langs[0]), strings.ToUpper(langs[1]))
+		for _, hit := range lang.Hits {
+			if len(hit.Roms) == 0 {
+				table([]Translation{{Source: hit.Source, Target: hit.Target}}, langs)
+				continue
+			}
+			for i, rom := range hit.Roms {
+				fmt.Fprintf(w, "### %s. %s%s\n\n", toRoman(i+1), rom.Headword, ansiEscape.ReplaceAllString(formatPhonetics(rom)+formatFrequency(rom, lang.Lang), ""))
+				for _, arab := range rom.Arabs {
+					if header := strings.TrimSpace(plainText(arab.Header)); header != "" {
+						fmt.Fprintf(w, "*%s*\n\n", header)
+					}
+					table(arab.Translations, langs)
+				}
+			}
+		}
+	}
+}
+
+// displayPlain renders results as text without colors nor tables, one
+// "source — target" pair per line under their headers.
+func displayPlain(w io.Writer, translations TranslationResponse, dict string) {
+	for _, lang := range translations {
+		fmt.Fprintf(w, "%s > %s\n", strings.ToUpper(lang.Lang), strings.ToUpper(getTargetLang(dict, lang.Lang)))
+		for _, hit := range lang.Hits {
+			if len(hit.Roms) == 0 {
+				fmt.Fprintf(w, "%s — %s\n", plainText(hit.Source), plainText(hit.Target))
+				continue
+			}
+			for i, rom := range hit.Roms {
+				fmt.Fprintf(w, "\n%s. %s%s\n", toRoman(i+1), rom.Headword, ansiEscape.ReplaceAllString(formatPhonetics(rom)+formatFrequency(rom, lang.Lang), ""))
+				for _, arab := range rom.Arabs {
+					if header := plainText(arab.Header); header != "" {
+						fmt.Fprintln(w, header)
+					}
+					for _, translation := range arab.Translations {
+						fmt.Fprintf(w, "%s — %s\n", plainText(translation.Source), plainText(translation.Target))
+					}
+				}
+			}
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// plainText flattens an HTML snippet without any color.
+func plainText(s string) string {
+	return ansiEscape.ReplaceAllString(parseHTML(s), "")
+}
+
+// isExample tells whether a source HTML snippet is an example phrase, which
+// PONS marks with the "example" class.
+func isExample(source string) bool {
+	doc, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return false
+	}
+	var f func(*html.Node) bool
+	f = func(n *html.Node) bool {
+		if n.Type == html.ElementNode && hasClass(n, "example") {
+			return true
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if f(c) {
+				return true
+			}
+		}
+		return false
+	}
+	return f(doc)
+}
+
+// selectExamples returns a copy of translations keeping only the example
+// phrases, or only what isn't one. Headers left without rows are dropped.
+func selectExamples(translations TranslationResponse, examples bool) TranslationResponse {
+	return filterTranslations(translations, func(t Translation) bool {
+		return isExample(t.Source) == examples
+	})
+}
+
+// filterTranslations returns a copy of translations keeping only the rows
+// keep accepts. Headers left without rows are dropped.
+func filterTranslations(translations TranslationResponse, keep func(Translation) bool) TranslationResponse {
+	var selected TranslationResponse
+	for _, lang := range translations {
+		var hits []Hit
+		for _, hit := range lang.Hits {
+			if len(hit.Roms) == 0 {
+				if keep(Translation{Source: hit.Source, Target: hit.Target}) {
+					hits = append(hits, hit)
+				}
+				continue
+			}
+			var roms []Rom
+			for _, rom := range hit.Roms {
+				var arabs []Arab
+				for _, arab := range rom.Arabs {
+					var rows []Translation
+					for _, translation := range arab.Translations {
+						if keep(translation) {
+							rows = append(rows, translation)
+						}
+					}
+					if len(rows) > 0 {
+						arab.Translations = rows
+						arabs = append(arabs, arab)
+					}
+				}
+				if len(arabs) > 0 {
+					rom.Arabs = arabs
+					roms = append(roms, rom)
+				}
+			}
+			if len(roms) > 0 {
+				hit.Roms = roms
+				hits = append(hits, hit)
+			}
+		}
+		if len(hits) > 0 {
+			lang.Hits = hits
+			selected = append(selected, lang)
+		}
+	}
+	return selected
+}
+
+func handleExamplesCommand() error {
+	result := getLastResult()
+	if result == nil {
+		return fmt.Errorf("no previous lookup")
+	}
+
+	examples := selectExamples(sortTranslations(result.Translations), true)
+	if len(examples) == 0 {
+		return fmt.Errorf("no examples for %s", result.Word)
+	}
+
+	var buf bytes.Buffer
+	tableRenderer{}.Render(&buf, examples, result.Dict, result.Word, getRenderOptions())
+	fmt.Fprint(color.Output, buf.String())
+	return nil
+}
+
+// handleGrepCommand renders the rows of the last result whose source or
+// target matches a pattern, as a case-insensitive regular expression or, if
+// it isn't a valid one, as plain text.
+func handleGrepCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: .grep <pattern>")
+	}
+	result := getLastResult()
+	if result == nil {
+		return fmt.Errorf("no previous lookup")
+	}
+
+	pattern := strings.Join(args, " ")
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		re = regexp.MustCompile("(?i)" + regexp.QuoteMeta(pattern))
+	}
+	matches := filterTranslations(result.Translations, func(t Translation) bool {
+		return re.MatchString(plainText(t.Source)) || re.MatchString(plainText(t.Target))
+	})
+	if len(matches) == 0 {
+		return fmt.Errorf("no rows matching %s in %s", pattern, result.Word)
+	}
+
+	var buf bytes.Buffer
+	renderTranslation(&buf, matches, result.Dict, result.Word)
+	fmt.Fprint(color.Output, buf.String())
+	return nil
+}
+
+// handleLastCommand renders the last lookup result again, without fetching
+// it, in the format given as argument if any.
+func handleLastCommand(args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("usage: .last [<format>]")
+	}
+
+	result := getLastResult()
+	if result == nil {
+		return fmt.Errorf("no previous lookup")
+	}
+
+	var buf bytes.Buffer
+	if len(args) == 1 {
+		if !slices.Contains(outputFormats, args[0]) {
+			return fmt.Errorf("unknown format: %s (available: %s)", args[0], strings.Join(outputFormats, ", "))
+		}
+		renderTranslationAs(&buf, args[0], result.Translations, result.Dict, result.Word)
+	} else {
+		renderTranslation(&buf, result.Translations, result.Dict, result.Word)
+	}
+	fmt.Fprint(color.Output, buf.String())
+	return nil
+}
+
+// handleAgainCommand looks the last lookup up again, bypassing the cache,
+// which is refreshed with the new result.
+func handleAgainCommand() error {
+	result := getLastResult()
+	if result == nil {
+		return fmt.Errorf("no previous lookup")
+	}
+
+	bypassCache.Store(true)
+	defer bypassCache.Store(false)
+	return lookupWord(result.Word, result.Dict)
+}
+
+// handleAsCommand renders the last lookup result again in another format,
+// e.g. to copy it into notes.
+func handleAsCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: .as <format> (available: %s)", strings.Join(outputFormats, ", "))
+	}
+	return handleLastCommand(args)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-runewidth"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+// renderFixtures are the PONS responses of testdata, with the dictionary
+// and the word they were looked up with.
+var renderFixtures = []struct {
+	name string
+	dict string
+	word string
+}{
+	{"latin", "deen", "Haus"},
+	{"cyrillic", "deru", "дом"},
+	{"arabic", "dear", "بيت"},
+	{"cjk", "dezh", "家"},
+	{"multi", "deen", "laufen"},
+}
+
+// renderCases are the renderers tested on each fixture, named after the
+// extension of their golden files.
+var renderCases = []struct {
+	name     string
+	renderer Renderer
+	opts     renderOptions
+}{
+	{"table", tableRenderer{}, renderOptions{Width: 100}},
+	{"narrow", tableRenderer{}, renderOptions{Width: 40}},
+	{"color", tableRenderer{}, renderOptions{Width: 100, Color: true}},
+	{"plain", plainRenderer{}, renderOptions{Width: 100}},
+	{"json", jsonRenderer{}, renderOptions{Width: 100}},
+	{"md", markdownRenderer{}, renderOptions{Width: 100}},
+}
+
+// setupRenderConfig sets the defaults of the settings the renderers read,
+// whatever the terminal and locale the tests run in.
+func setupRenderConfig(t *testing.T) {
+	previousConfig, previousNoColor, previousEastAsian := config, color.NoColor, runewidth.DefaultCondition.EastAsianWidth
+	t.Cleanup(func() {
+		config, color.NoColor, runewidth.DefaultCondition.EastAsianWidth = previousConfig, previousNoColor, previousEastAsian
+	})
+
+	config = Config{
+		RTLMode:             "swap",
+		ShowExamples:        true,
+		GenderColors:        true,
+		GenderPalette:       "m=blue,f=red,n=green",
+		Hyperlinks:          "off",
+		TableStyle:          "none",
+		Sort:                "relevance",
+		Phonetics:           true,
+		FrequencyAnnotation: "off",
+	}
+	color.NoColor = false
+	runewidth.DefaultCondition.EastAsianWidth = false
+}
+
+func TestRenderGolden(t *testing.T) {
+	setupRenderConfig(t)
+
+	for _, fixture := range renderFixtures {
+		data, err := os.ReadFile(filepath.Join("testdata", fixture.name+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var translations TranslationResponse
+		if err := json.Unmarshal(data, &translations); err != nil {
+			t.Fatalf("%s: %v", fixture.name, err)
+		}
+
+		for _, c := range renderCases {
+			t.Run(fixture.name+"/"+c.name, func(t *testing.T) {
+				var buf bytes.Buffer
+				c.renderer.Render(&buf, translations, fixture.dict, fixture.word, c.opts)
+
+				golden := filepath.Join("testdata", fixture.name+"."+c.name+".golden")
+				if *update {
+					if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("%v, run go test -update to create it", err)
+				}
+				if got := buf.String(); got != string(want) {
+					t.Errorf("output differs from %s:\ngot:\n%s\nwant:\n%s", golden, got, want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,91 @@
+//go:build !windows
+
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// resizeWaker wakes the input of the REPL up on resize.
+var resizeWaker *os.File
+
+// resizeStdin is the input of readline, which reads resizeRune when woken
+// up by resizeWaker, and stdin otherwise. Stdin is only read when it has
+// input, so that nothing is taken from the commands reading it themselves.
+type resizeStdin struct {
+	wake *os.File
+}
+
+// newReplStdin returns the input of the REPL, which redraws on resize.
+func newReplStdin() io.ReadCloser {
+	r, w, err := os.Pipe()
+	if err != nil {
+		slog.Warn("could not watch resizes", "err", err)
+		return nil
+	}
+	resizeWaker = w
+	return resizeStdin{wake: r}
+}
+
+func (s resizeStdin) Read(p []byte) (int, error) {
+	for {
+		fds := []unix.PollFd{
+			{Fd: int32(os.Stdin.Fd()), Events: unix.POLLIN},
+			{Fd: int32(s.wake.Fd()), Events: unix.POLLIN},
+		}
+		if _, err := unix.Poll(fds, -1); err != nil {
+			if err == unix.EINTR {
+				continue
+			}
+			return 0, err
+		}
+		if fds[1].Revents&unix.POLLIN != 0 {
+			var b [1]byte
+			if _, err := s.wake.Read(b[:]); err != nil {
+				return 0, err
+			}
+			return copy(p, string(resizeRune)), nil
+		}
+		return os.Stdin.Read(p)
+	}
+}
+
+func (s resizeStdin) Close() error {
+	return s.wake.Close()
+}
+
+// requestRedraw asks the REPL to redraw the last result, when it waits at
+// its prompt.
+func requestRedraw() {
+	if resizeWaker == nil || !atReplPrompt.Load() {
+		return
+	}
+	if _, err := resizeWaker.Write([]byte{0}); err != nil {
+		slog.Warn("could not request redraw", "err", err)
+	}
+}
+
+// watchResize asks the REPL to redraw the last result when the terminal is
+// resized. Signals are debounced, as dragging a window edge sends a burst
+// of them.
+func watchResize() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGWINCH)
+
+	go func() {
+		var timer *time.Timer
+		for range sigs {
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(300*time.Millisecond, requestRedraw)
+		}
+	}()
+}
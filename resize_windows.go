@@ -0,0 +1,14 @@
+//go:build windows
+
+package main
+
+import "io"
+
+// newReplStdin returns nil for readline to read the console, as resizes
+// aren't watched on Windows.
+func newReplStdin() io.ReadCloser {
+	return nil
+}
+
+// watchResize is a no-op on Windows, which has no SIGWINCH.
+func watchResize() {}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// revalidationGrace is how long expired cache entries with validators are
+// kept, to be revalidated rather than downloaded again.
+const revalidationGrace = 30 * 24 * time.Hour
+
+const validatorsSuffix = ".validators"
+
+// cacheValidators are the validators of a cached response, sent back with
+// conditional requests.
+type cacheValidators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+func isValidatorsFile(path string) bool {
+	return strings.HasSuffix(path, validatorsSuffix)
+}
+
+func readCacheValidators(cacheFile string) (cacheValidators, error) {
+	var validators cacheValidators
+	data, err := os.ReadFile(cacheFile + validatorsSuffix)
+	if err != nil {
+		return validators, err
+	}
+	if err := json.Unmarshal(data, &validators); err != nil {
+		return validators, fmt.Errorf("could not unmarshal cache validators: %w", err)
+	}
+	return validators, nil
+}
+
+func hasCacheValidators(cacheFile string) bool {
+	_, err := os.Stat(cacheFile + validatorsSuffix)
+	return err == nil
+}
+
+// writeCacheValidators saves the validators of a response next to its
+// cache file, or removes the previous ones if it has none.
+func writeCacheValidators(cacheFile string, header http.Header) error {
+	validators := cacheValidators{
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+	}
+	if validators == (cacheValidators{}) {
+		removeCacheValidators(cacheFile)
+		return nil
+	}
+	data, err := json.Marshal(validators)
+	if err != nil {
+		return fmt.Errorf("could not marshal cache validators: %w", err)
+	}
+	return os.WriteFile(cacheFile+validatorsSuffix, data, 0644)
+}
+
+func removeCacheValidators(cacheFile string) {
+	if err := os.Remove(cacheFile + validatorsSuffix); err != nil && !os.IsNotExist(err) {
+		slog.Warn("could not remove cache validators", "err", err)
+	}
+}
+
+// setCacheValidators makes req conditional on the cached response having
+// changed, if its validators are known.
+func setCacheValidators(req *http.Request, cacheFile string) {
+	validators, err := readCacheValidators(cacheFile)
+	if err != nil {
+		return
+	}
+	if validators.ETag != "" {
+		req.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validators.LastModified)
+	}
+}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/eiannone/keyboard"
+	"github.com/fatih/color"
+)
+
+// Grades of a review, from a forgotten card to an easy one.
+const (
+	gradeAgain = 1
+	gradeHard  = 2
+	gradeGood  = 3
+	gradeEasy  = 4
+)
+
+// minEase is the lowest ease of SM-2, below which cards would come back too
+// often.
+const minEase = 1.3
+
+// relearnDelay is when a forgotten card comes back.
+const relearnDelay = 10 * time.Minute
+
+// scheduleSM2 updates the scheduling of a card after a review, following
+// the SM-2 algorithm of SuperMemo with Anki's four grades.
+func scheduleSM2(card flashcard, grade int, now time.Time) flashcard {
+	switch grade {
+	case gradeAgain:
+		card.Repetitions = 0
+		card.Interval = 0
+		card.Ease = math.Max(minEase, card.Ease-0.2)
+		card.Due = now.Add(relearnDelay)
+		return card
+	case gradeHard:
+		card.Interval = max(1, int(math.Round(float64(card.Interval)*1.2)))
+		card.Ease = math.Max(minEase, card.Ease-0.15)
+	default:
+		switch card.Repetitions {
+		case 0:
+			card.Interval = 1
+		case 1:
+			card.Interval = 6
+		default:
+			card.Interval = int(math.Round(float64(card.Interval) * card.Ease))
+		}
+		if grade == gradeEasy {
+			card.Interval = int(math.Round(float64(card.Interval) * 1.3))
+			card.Ease += 0.15
+		}
+	}
+	card.Repetitions++
+	card.Due = now.AddDate(0, 0, card.Interval)
+	return card
+}
+
+// getDueFlashcards returns the cards due for review, of dict if not empty,
+// and of deck if not nil.
+func getDueFlashcards(dict string, deck *string, now time.Time) ([]flashcard, error) {
+	query := `
+		SELECT id, word, dict, kind, note, front, back, due, interval, ease, repetitions,
+			NOT EXISTS(SELECT 1 FROM review_log WHERE card_id = flashcards.id)
+		FROM flashcards WHERE due <= ?`
+	args := []any{now}
+	if dict != "" {
+		query += " AND dict = ?"
+		args = append(args, dict)
+	}
+	if deck != nil {
+		query += " AND deck = ?"
+		args = append(args, *deck)
+	}
+	rows, err := db.Query(query+" ORDER BY due ASC", args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query flashcards: %w", err)
+	}
+	defer rows.Close()
+
+	var cards []flashcard
+	for rows.Next() {
+		var c flashcard
+		if err := rows.Scan(&c.ID, &c.Word, &c.Dict, &c.Kind, &c.Note, &c.Front, &c.Back, &c.Due, &c.Interval, &c.Ease, &c.Repetitions, &c.New); err != nil {
+			return nil, fmt.Errorf("could not scan row: %w", err)
+		}
+		cards = append(cards, c)
+	}
+	return cards, rows.Err()
+}
+
+// saveReview stores the new scheduling of a card along with its review,
+// returning the ID of the review in the log.
+func saveReview(card flashcard, grade int, now time.Time) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("could not save review: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE flashcards SET due = ?, interval = ?, ease = ?, repetitions = ? WHERE id = ?",
+		card.Due, card.Interval, card.Ease, card.Repetitions, card.ID); err != nil {
+		return 0, fmt.Errorf("could not save review: %w", err)
+	}
+	result, err := tx.Exec("INSERT INTO review_log(card_id, date, grade, interval, ease) VALUES(?, ?, ?, ?, ?)",
+		card.ID, now, grade, card.Interval, card.Ease)
+	if err != nil {
+		return 0, fmt.Errorf("could not save review: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("could not save review: %w", err)
+	}
+	return id, tx.Commit()
+}
+
+// review is a review saved during a session, which can be undone.
+type review struct {
+	// Card is the card as it was before the review.
+	Card  flashcard
+	LogID int64
+	Date  time.Time
+	// Index is the index of the card in the session.
+	Index int
+}
+
+// undoReview restores the scheduling a card had before a review, and
+// removes the review from the log and the activity.
+func undoReview(r review) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not undo review: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE flashcards SET due = ?, interval = ?, ease = ?, repetitions = ? WHERE id = ?",
+		r.Card.Due, r.Card.Interval, r.Card.Ease, r.Card.Repetitions, r.Card.ID); err != nil {
+		return fmt.Errorf("could not undo review: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM review_log WHERE id = ?", r.LogID); err != nil {
+		return fmt.Errorf("could not undo review: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE activity SET reviews = MAX(0, reviews - 1) WHERE day = ?", r.Date.Format("2006-01-02")); err != nil {
+		return fmt.Errorf("could not undo review: %w", err)
+	}
+	return tx.Commit()
+}
+
+// reviewLimits caps the cards of a review session. Zero means no limit.
+type reviewLimits struct {
+	New  int
+	Due  int
+	Time time.Duration
+	// Deck is the deck reviewed, if not nil.
+	Deck *string
+}
+
+// parseReviewArgs parses the arguments of .review: a dictionary, and the
+// deck and limits of the session.
+func parseReviewArgs(args []string) (string, reviewLimits, error) {
+	usage := fmt.Errorf("usage: .review [<dict>] [--deck <name>] [--new <n>] [--due <n>] [--time <duration>]")
+	dict := ""
+	var limits reviewLimits
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "--") {
+			if dict != "" {
+				return "", limits, usage
+			}
+			dict = args[i]
+			continue
+		}
+		if i+1 == len(args) {
+			return "", limits, usage
+		}
+		flag, value := args[i], args[i+1]
+		i++
+		var err error
+		switch flag {
+		case "--new":
+			limits.New, err = strconv.Atoi(value)
+			if err == nil && limits.New <= 0 {
+				err = errors.New("not positive")
+			}
+		case "--due":
+			limits.Due, err = strconv.Atoi(value)
+			if err == nil && limits.Due <= 0 {
+				err = errors.New("not positive")
+			}
+		case "--deck":
+			deck := getDeck(value)
+			limits.Deck = &deck
+		case "--time":
+			limits.Time, err = time.ParseDuration(value)
+			if err == nil && limits.Time <= 0 {
+				err = errors.New("not positive")
+			}
+		default:
+			return "", limits, usage
+		}
+		if err != nil {
+			return "", limits, fmt.Errorf("invalid value for %s: %s", flag, value)
+		}
+	}
+	return dict, limits, nil
+}
+
+// countReviewedToday returns the number of new cards and of cards reviewed
+// before seen today. Dates are saved in the local time zone, like today is
+// compared in.
+func countReviewedToday(now time.Time) (int, int, error) {
+	today := startOfDay(now).Local()
+	var newCards, dueCards int
+	err := db.QueryRow(`
+		SELECT COALESCE(SUM(first >= ?), 0), COALESCE(SUM(first < ?), 0)
+		FROM (
+			SELECT r.card_id, (SELECT MIN(date) FROM review_log WHERE card_id = r.card_id) AS first
+			FROM review_log r
+			WHERE r.date >= ?
+			GROUP BY r.card_id
+		)
+	`, today, today, today).Scan(&newCards, &dueCards)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not count reviews: %w", err)
+	}
+	return newCards, dueCards, nil
+}
+
+// limitCards keeps the cards within the limits of the session and what's
+// left of new_cards_per_day and reviews_per_day.
+func limitCards(cards []flashcard, limits reviewLimits, now time.Time) ([]flashcard, error) {
+	newToday, dueToday, err := countReviewedToday(now)
+	if err != nil {
+		return nil, err
+	}
+	// limit returns how many cards the session may show, -1 for no limit
+	limit := func(session, perDay, today int) int {
+		if perDay == 0 {
+			if session == 0 {
+				return -1
+			}
+			return session
+		}
+		left := max(0, perDay-today)
+		if session == 0 || session > left {
+			return left
+		}
+		return session
+	}
+	newLimit := limit(limits.New, config.NewCardsPerDay, newToday)
+	dueLimit := limit(limits.Due, config.ReviewsPerDay, dueToday)
+
+	var kept []flashcard
+	newCards, dueCards := 0, 0
+	for _, card := range cards {
+		if card.New {
+			if newCards == newLimit {
+				continue
+			}
+			newCards++
+		} else {
+			if dueCards == dueLimit {
+				continue
+			}
+			dueCards++
+		}
+		kept = append(kept, card)
+	}
+	return kept, nil
+}
+
+// handleReviewCommand reviews the due flashcards, of a dictionary if given.
+func handleReviewCommand(args []string) error {
+	dict, limits, err := parseReviewArgs(args)
+	if err != nil {
+		return err
+	}
+
+	scheduler, err := getScheduler()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if limits.Deck != nil {
+		if err := requireDeck(*limits.Deck); err != nil {
+			return err
+		}
+	}
+	cards, err := getDueFlashcards(dict, limits.Deck, now)
+	if err != nil {
+		return err
+	}
+	if len(cards) == 0 {
+		color.New(color.FgYellow).Println("No cards due for review.")
+		return nil
+	}
+	if cards, err = limitCards(cards, limits, now); err != nil {
+		return err
+	}
+	if len(cards) == 0 {
+		color.New(color.FgYellow).Println("Daily limit reached, see new_cards_per_day and reviews_per_day.")
+		return nil
+	}
+	var deadline time.Time
+	if limits.Time > 0 {
+		deadline = now.Add(limits.Time)
+	}
+
+	reviewed := 0
+	// last is the last review, undone with u until the next one
+	var last *review
+	undo := func() error {
+		if err := undoReview(*last); err != nil {
+			return err
+		}
+		color.New(color.FgYellow).Printf("Undid the grade of %s\n", last.Card.Front)
+		reviewed--
+		return nil
+	}
+	for i := 0; i < len(cards); i++ {
+		card := cards[i]
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			color.New(color.FgYellow).Printf("\nTime is up, %d cards left\n", len(cards)-i)
+			break
+		}
+		color.New(color.FgRed, color.Bold).Printf("\n[%d/%d] %s\n", i+1, len(cards), card.Dict)
+		color.New(color.FgYellow, color.Bold).Println(card.Front)
+		if last != nil {
+			color.New(color.FgYellow).Println("press any key to see the answer, u to undo the last grade, or ESC to stop reviewing")
+		} else {
+			color.New(color.FgYellow).Println("press any key to see the answer, or ESC to stop reviewing")
+		}
+		char, key, err := keyboard.GetSingleKey()
+		if err != nil {
+			return err
+		}
+		if key == keyboard.KeyEsc {
+			break
+		}
+		if char == 'u' && last != nil {
+			if err := undo(); err != nil {
+				return err
+			}
+			// The card is shown again, to be graded anew
+			i = last.Index - 1
+			last = nil
+			continue
+		}
+
+		fmt.Println(card.Back)
+		if card.Kind == "cloze" {
+			color.New(color.FgGreen).Println(card.Word)
+		}
+		if card.Note != "" {
+			color.New(color.FgCyan).Println(card.Note)
+		}
+
+		grade, ok, err := readGrade()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+
+		now := time.Now()
+		logID, err := saveReview(scheduler.Schedule(card, grade, now), grade, now)
+		if err != nil {
+			return err
+		}
+		reviewed++
+		last = &review{Card: card, LogID: logID, Date: now, Index: i}
+
+		if err := recordActivity(0, 1); err != nil {
+			// Log the error, but don't fail the command
+			slog.Warn("could not record activity", "err", err)
+		}
+
+		if i == len(cards)-1 {
+			// The last grade can be undone before leaving
+			color.New(color.FgYellow).Println("press u to undo the last grade, or any other key to finish")
+			char, _, err := keyboard.GetSingleKey()
+			if err != nil {
+				return err
+			}
+			if char == 'u' {
+				if err := undo(); err != nil {
+					return err
+				}
+				i = last.Index - 1
+				last = nil
+			}
+		}
+	}
+
+	color.New(color.FgGreen).Printf("\n%d cards reviewed\n", reviewed)
+	return nil
+}
+
+// gradeKeys are the letters grading a review, besides 1 to 4.
+var gradeKeys = map[rune]int{'a': gradeAgain, 'h': gradeHard, 'g': gradeGood, 'e': gradeEasy}
+
+// readGrade waits for the grade of a review, a single key press, returning
+// false when the user stops reviewing.
+func readGrade() (int, bool, error) {
+	color.New(color.FgYellow).Println("grade: 1/a again, 2/h hard, 3/g good, 4/e easy, or ESC to stop reviewing")
+	for {
+		char, key, err := keyboard.GetSingleKey()
+		if err != nil {
+			return 0, false, err
+		}
+		if key == keyboard.KeyEsc {
+			return 0, false, nil
+		}
+		if char >= '1' && char <= '4' {
+			return int(char - '0'), true, nil
+		}
+		if grade, ok := gradeKeys[unicode.ToLower(char)]; ok {
+			return grade, true, nil
+		}
+	}
+}
+
+// countFlashcards returns the number of saved cards and of due ones.
+func countFlashcards(now time.Time) (int, int, error) {
+	var total, due int
+	err := db.QueryRow("SELECT COUNT(*), COALESCE(SUM(due <= ?), 0) FROM flashcards", now).Scan(&total, &due)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not count flashcards: %w", err)
+	}
+	return total, due, nil
+}
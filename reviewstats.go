@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// reviewStatsDays is how far back the retention rate is computed.
+const reviewStatsDays = 30
+
+// forecastDays is the number of days of the forecast of due cards.
+const forecastDays = 7
+
+// showReviewStats shows the retention rate, the average ease and the due
+// cards of each dictionary and deck, followed by the cards due in the next
+// days.
+func showReviewStats() error {
+	now := time.Now()
+	since := now.AddDate(0, 0, -reviewStatsDays)
+
+	color.New(color.FgGreen).Printf("Flashcards, with the reviews of the last %d days\n", reviewStatsDays)
+	if err := showReviewStatsBy("dict", "Dictionary", since, now); err != nil {
+		return err
+	}
+	fmt.Println()
+	if err := showReviewStatsBy("deck", "Deck", since, now); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	return showDueForecast(now)
+}
+
+// showReviewStatsBy shows the review stats of the cards grouped by column,
+// dict or deck.
+func showReviewStatsBy(column, header string, since, now time.Time) error {
+	// The retention rate is the share of the reviews not forgotten
+	rows, err := db.Query(fmt.Sprintf(`
+		SELECT f.%[1]s,
+			COUNT(*),
+			COALESCE(AVG(f.ease), 0),
+			COALESCE(SUM(f.due <= ?), 0),
+			COALESCE(SUM(r.reviews), 0),
+			COALESCE(SUM(r.remembered), 0)
+		FROM flashcards f
+		LEFT JOIN (
+			SELECT card_id, COUNT(*) AS reviews, SUM(grade > ?) AS remembered
+			FROM review_log
+			WHERE date >= ?
+			GROUP BY card_id
+		) r ON r.card_id = f.id
+		GROUP BY f.%[1]s
+		ORDER BY f.%[1]s
+	`, column), now, gradeAgain, since)
+	if err != nil {
+		return fmt.Errorf("could not query review stats: %w", err)
+	}
+	defer rows.Close()
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{header, "Cards", "Due", "Reviews", "Retention", "Avg Ease"})
+	t.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 2, Align: text.AlignRight},
+		{Number: 3, Align: text.AlignRight},
+		{Number: 4, Align: text.AlignRight},
+		{Number: 5, Align: text.AlignRight},
+		{Number: 6, Align: text.AlignRight},
+	})
+	count := 0
+	for rows.Next() {
+		var name string
+		var cards, due, reviews, remembered int
+		var ease float64
+		if err := rows.Scan(&name, &cards, &ease, &due, &reviews, &remembered); err != nil {
+			return fmt.Errorf("could not scan row: %w", err)
+		}
+		if name == "" {
+			name = "(default)"
+		}
+		retention := "-"
+		if reviews > 0 {
+			retention = fmt.Sprintf("%d%%", 100*remembered/reviews)
+		}
+		t.AppendRow(table.Row{name, cards, due, reviews, retention, fmt.Sprintf("%.2f", ease)})
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("no flashcards, use .save to save some")
+	}
+	t.Render()
+	return nil
+}
+
+// showDueForecast shows the number of cards due each of the next days, the
+// cards overdue being counted today.
+func showDueForecast(now time.Time) error {
+	today := startOfDay(now)
+	end := today.AddDate(0, 0, forecastDays)
+
+	rows, err := db.Query("SELECT due FROM flashcards WHERE due < ?", end)
+	if err != nil {
+		return fmt.Errorf("could not query flashcards: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make([]int, forecastDays)
+	for rows.Next() {
+		var due time.Time
+		if err := rows.Scan(&due); err != nil {
+			return fmt.Errorf("could not scan row: %w", err)
+		}
+		day := 0
+		for day < forecastDays-1 && !due.Before(today.AddDate(0, 0, day+1)) {
+			day++
+		}
+		counts[day]++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Day", "Due"})
+	t.SetColumnConfigs([]table.ColumnConfig{{Number: 2, Align: text.AlignRight}})
+	for day, count := range counts {
+		name := today.AddDate(0, 0, day).Format("Mon 2006-01-02")
+		if day == 0 {
+			name = "Today"
+		}
+		t.AppendRow(table.Row{name, count})
+	}
+	color.New(color.FgGreen).Printf("Cards due in the next %d days\n", forecastDays)
+	t.Render()
+	return nil
+}
+
+// exportReviews writes the review log to a CSV file, a review per row with
+// its card, for Anki or analyses of one's own.
+func exportReviews(path string) error {
+	rows, err := db.Query(`
+		SELECT r.card_id, f.word, f.dict, f.kind, f.deck, r.date, r.grade, r.interval, r.ease
+		FROM review_log r
+		JOIN flashcards f ON f.id = r.card_id
+		ORDER BY r.date, r.id
+	`)
+	if err != nil {
+		return fmt.Errorf("could not query review log: %w", err)
+	}
+	defer rows.Close()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create reviews file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"card_id", "word", "dict", "kind", "deck", "date", "grade", "interval", "ease"})
+	count := 0
+	for rows.Next() {
+		var id int64
+		var word, dict, kind, deck string
+		var date time.Time
+		var grade, interval int
+		var ease float64
+		if err := rows.Scan(&id, &word, &dict, &kind, &deck, &date, &grade, &interval, &ease); err != nil {
+			return fmt.Errorf("could not scan row: %w", err)
+		}
+		writer.Write([]string{
+			strconv.FormatInt(id, 10),
+			word,
+			dict,
+			kind,
+			deck,
+			date.Format(time.RFC3339),
+			strconv.Itoa(grade),
+			strconv.Itoa(interval),
+			strconv.FormatFloat(ease, 'f', 2, 64),
+		})
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("could not write reviews: %w", err)
+	}
+
+	color.New(color.FgGreen).Printf("%d reviews exported to %s\n", count, path)
+	return nil
+}
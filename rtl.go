@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"golang.org/x/text/unicode/bidi"
+)
+
+// Languages written in a right-to-left script
+var rtlLanguages = []string{"ar", "fa", "he", "ur", "yi"}
+
+var mirroredRunes = map[rune]rune{
+	'(': ')', ')': '(',
+	'[': ']', ']': '[',
+	'{': '}', '}': '{',
+	'<': '>', '>': '<',
+	'«': '»', '»': '«',
+}
+
+func isRTL(lang string) bool {
+	return slices.Contains(rtlLanguages, lang)
+}
+
+// getTargetLang returns the other language of a dictionary, e.g. "en" for the
+// "de" side of "deen".
+func getTargetLang(dict, lang string) string {
+	return strings.Replace(dict, lang, "", 1)
+}
+
+// applyRTLMode reorders the right-to-left cells of rows from logical to
+// visual order when rtl_mode is "visual", for terminals without
+// bidirectional text support.
+func applyRTLMode(rows []table.Row, rtl [2]bool) []table.Row {
+	if config.RTLMode != "visual" || (!rtl[0] && !rtl[1]) {
+		return rows
+	}
+
+	visual := make([]table.Row, len(rows))
+	for i, row := range rows {
+		visual[i] = slices.Clone(row)
+		for col := range rtl {
+			if rtl[col] {
+				// Escape sequences would be scrambled by reordering
+				lines := strings.Split(ansiEscape.ReplaceAllString(fmt.Sprint(row[col]), ""), "\n")
+				for j, line := range lines {
+					lines[j] = toVisualOrder(line)
+				}
+				visual[i][col] = strings.Join(lines, "\n")
+			}
+		}
+	}
+	return visual
+}
+
+func swapColumns(rows []table.Row) []table.Row {
+	swapped := make([]table.Row, len(rows))
+	for i, row := range rows {
+		swapped[i] = table.Row{row[1], row[0]}
+	}
+	return swapped
+}
+
+// toVisualOrder lays out a right-to-left paragraph in the order it must be
+// displayed from left to right: runs are printed last to first, and
+// characters of right-to-left runs are reversed, brackets being mirrored.
+func toVisualOrder(s string) string {
+	var p bidi.Paragraph
+	if _, err := p.SetString(s, bidi.DefaultDirection(bidi.RightToLeft)); err != nil {
+		return s
+	}
+	ordering, err := p.Order()
+	if err != nil {
+		return s
+	}
+
+	runs := make([]string, ordering.NumRuns())
+	for i := range runs {
+		run := ordering.Run(i)
+		if run.Direction() == bidi.RightToLeft {
+			runs[i] = reverseRTLRun(run.String())
+		} else {
+			runs[i] = run.String()
+		}
+	}
+	if ordering.Direction() != bidi.LeftToRight {
+		slices.Reverse(runs)
+	}
+	return strings.Join(runs, "")
+}
+
+// reverseRTLRun reverses the characters of s, keeping combining marks (such
+// as Arabic harakat or Hebrew niqqud) after the letter they belong to.
+func reverseRTLRun(s string) string {
+	var clusters [][]rune
+	for _, r := range s {
+		if unicode.Is(unicode.Mn, r) && len(clusters) > 0 {
+			clusters[len(clusters)-1] = append(clusters[len(clusters)-1], r)
+			continue
+		}
+		if m, ok := mirroredRunes[r]; ok {
+			r = m
+		}
+		clusters = append(clusters, []rune{r})
+	}
+
+	var sb strings.Builder
+	for i := len(clusters) - 1; i >= 0; i-- {
+		sb.WriteString(string(clusters[i]))
+	}
+	return sb.String()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Scheduler is a spaced repetition algorithm, which decides when a card
+// comes back after a review.
+type Scheduler interface {
+	// Schedule returns the card with its new scheduling, after a review
+	// graded from gradeAgain to gradeEasy.
+	Schedule(card flashcard, grade int, now time.Time) flashcard
+}
+
+var schedulers = map[string]Scheduler{}
+
+func registerScheduler(name string, scheduler Scheduler) {
+	schedulers[name] = scheduler
+}
+
+func init() {
+	registerScheduler("sm2", sm2Scheduler{})
+	registerScheduler("leitner", leitnerScheduler{})
+}
+
+func getScheduler() (Scheduler, error) {
+	scheduler, ok := schedulers[config.SRSAlgorithm]
+	if !ok {
+		return nil, fmt.Errorf("unknown srs_algorithm: %s", config.SRSAlgorithm)
+	}
+	return scheduler, nil
+}
+
+func getSchedulerNames() []string {
+	names := make([]string, 0, len(schedulers))
+	for name := range schedulers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type sm2Scheduler struct{}
+
+func (sm2Scheduler) Schedule(card flashcard, grade int, now time.Time) flashcard {
+	return scheduleSM2(card, grade, now)
+}
+
+// leitnerIntervals are the days between the reviews of the cards of each
+// box of the Leitner system.
+var leitnerIntervals = []int{1, 2, 4, 8, 16, 32}
+
+// leitnerScheduler moves the cards between the boxes of the Leitner system:
+// a card remembered moves up a box, two when easy, stays in its box when
+// hard, and goes back to the first box when forgotten. The box of a card is
+// kept as its repetitions.
+type leitnerScheduler struct{}
+
+func (leitnerScheduler) Schedule(card flashcard, grade int, now time.Time) flashcard {
+	// New cards are in no box yet
+	box := card.Repetitions
+	switch grade {
+	case gradeAgain:
+		card.Repetitions = 1
+		card.Interval = 0
+		card.Due = now.Add(relearnDelay)
+		return card
+	case gradeHard:
+		box = max(box, 1)
+	case gradeEasy:
+		box += 2
+	default:
+		box++
+	}
+	box = min(box, len(leitnerIntervals))
+
+	card.Repetitions = box
+	card.Interval = leitnerIntervals[box-1]
+	card.Due = now.AddDate(0, 0, card.Interval)
+	return card
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// clearScreen clears the terminal and moves the cursor to its top left
+// corner.
+func clearScreen() {
+	// color.Output translates the sequence on Windows consoles without
+	// virtual terminal support
+	fmt.Fprint(color.Output, "\033[H\033[2J")
+}
+
+// printHeader prints the lines shown above the first prompt of the REPL.
+func printHeader() {
+	if len(getAPIKeys()) == 0 {
+		color.New(color.FgYellow).Print(welcomeMessage)
+		fmt.Println("")
+	}
+
+	color.New(color.FgYellow).Println("Type .help for more information.")
+}
+
+// handleClearCommand clears the terminal, and prints the header of the REPL
+// again if asked to.
+func handleClearCommand(args []string) error {
+	if len(args) > 1 || (len(args) == 1 && args[0] != "header") {
+		return fmt.Errorf("usage: .clear [header]")
+	}
+	clearScreen()
+	if len(args) == 1 && !quietOutput {
+		printHeader()
+	}
+	return nil
+}
+
+// clearBetweenResults clears the terminal before the lookups of a line
+// typed at the REPL prompt, when clear_between_results is on. Lines of
+// commands are left alone.
+func clearBetweenResults(input string) {
+	input = strings.TrimSpace(input)
+	if !config.ClearBetweenResults || input == "" || strings.HasPrefix(input, ".") {
+		return
+	}
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		clearScreen()
+	}
+}
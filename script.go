@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxSourceDepth bounds nested .source commands, so that a script sourcing
+// itself doesn't loop forever.
+const maxSourceDepth = 8
+
+var sourceDepth int
+
+// getRCFile returns the path of the script run when the REPL starts.
+func getRCFile() string {
+	return filepath.Join(getAppConfigDir(), "rc")
+}
+
+// runRCFile runs the startup script, if there is one. It returns true when
+// the script asks to quit.
+func runRCFile() (bool, error) {
+	quit, err := runScript(getRCFile())
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return quit, err
+}
+
+// runScript runs the REPL commands of a file, one per line. Empty lines and
+// lines starting with # are skipped. It returns true when the script asks to
+// quit.
+func runScript(path string) (bool, error) {
+	if sourceDepth >= maxSourceDepth {
+		return false, fmt.Errorf("too many nested .source commands")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	sourceDepth++
+	defer func() { sourceDepth-- }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if runCommand(line) {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	return false, nil
+}
+
+func handleSourceCommand(args []string) (bool, error) {
+	if len(args) != 1 {
+		return false, fmt.Errorf("usage: .source <file>")
+	}
+	return runScript(args[0])
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/fatih/color"
+)
+
+// allSections expands every section, whatever collapse_sections says.
+const allSections = -1
+
+// shownSection is the word class section expanded with .show, or 0 when
+// none was picked since the last lookup.
+var shownSection atomic.Int64
+
+// wordClassSection gathers the roms of a language sharing a word class.
+type wordClassSection struct {
+	WordClass string
+	Roms      []Rom
+}
+
+// groupByWordClass groups the roms of hits by word class, in the order the
+// word classes first appear, or in alphabetical order if sort is
+// "wordclass". Hits without roms are returned apart.
+func groupByWordClass(hits []Hit) ([]wordClassSection, []Hit) {
+	var sections []wordClassSection
+	var others []Hit
+	index := map[string]int{}
+	for _, hit := range hits {
+		if len(hit.Roms) == 0 {
+			others = append(others, hit)
+			continue
+		}
+		for _, rom := range hit.Roms {
+			if rom.Origin == "" {
+				rom.Origin = getHitOrigin(hit)
+			}
+			i, ok := index[rom.WordClass]
+			if !ok {
+				i = len(sections)
+				index[rom.WordClass] = i
+				sections = append(sections, wordClassSection{WordClass: rom.WordClass})
+			}
+			sections[i].Roms = append(sections[i].Roms, rom)
+		}
+	}
+	if config.Sort == "wordclass" {
+		slices.SortStableFunc(sections, func(a, b wordClassSection) int {
+			return strings.Compare(a.WordClass, b.WordClass)
+		})
+	}
+	return sections, others
+}
+
+// isSectionShown tells whether the nth section (counted from 1) of a
+// result is expanded.
+func isSectionShown(n int) bool {
+	switch shown := int(shownSection.Load()); shown {
+	case allSections:
+		return true
+	case 0:
+		return !config.CollapseSections || n == 1
+	default:
+		return n == shown
+	}
+}
+
+func handleShowCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: .show <n>|#<n>|all")
+	}
+
+	result := getLastResult()
+	if result == nil {
+		return fmt.Errorf("no previous lookup")
+	}
+
+	if strings.HasPrefix(args[0], "#") {
+		return showEntry(result, args[0])
+	}
+	if args[0] == "all" {
+		shownSection.Store(allSections)
+	} else {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 || n > countSections(result.Translations) {
+			return fmt.Errorf("no such section: %s", args[0])
+		}
+		shownSection.Store(int64(n))
+	}
+
+	var buf bytes.Buffer
+	renderTranslation(&buf, result.Translations, result.Dict, result.Word)
+	fmt.Fprint(color.Output, buf.String())
+	return nil
+}
+
+func countSections(translations TranslationResponse) int {
+	count := 0
+	for _, lang := range translations {
+		sections, _ := groupByWordClass(lang.Hits)
+		count += len(sections)
+	}
+	return count
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+const ponsTextTranslationURL = "https://api.pons.com/text-translation-web/v4/translate"
+const deeplURL = "https://api.deepl.com/v2/translate"
+const deeplFreeURL = "https://api-free.deepl.com/v2/translate"
+const defaultLibreTranslateURL = "https://libretranslate.com"
+
+// Machine translation providers, used to translate whole sentences.
+var sentenceTranslators = map[string]func(text, source, target string) (string, error){
+	"pons":           translateWithPONS,
+	"deepl":          translateWithDeepL,
+	"libretranslate": translateWithLibreTranslate,
+}
+
+func handleTranslateCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: .translate <sentence>")
+	}
+	if currentDict == "" || len(currentDict) != 4 {
+		return fmt.Errorf("no dictionary selected. Use .dict <key> to select one")
+	}
+
+	translate, ok := sentenceTranslators[config.MTProvider]
+	if !ok {
+		return fmt.Errorf("unknown mt_provider: %s", config.MTProvider)
+	}
+
+	text := strings.Join(args, " ")
+	source, target := currentDict[:2], currentDict[2:]
+	translation, err := translate(text, source, target)
+	if err != nil {
+		return err
+	}
+
+	color.New(color.FgRed, color.Bold).Printf("\n%s > %s\n", strings.ToUpper(source), strings.ToUpper(target))
+	color.New(color.FgGreen).Println(text)
+	fmt.Println(translation)
+	fmt.Println()
+
+	if err := appendTranscript(text, currentDict, translation+"\n"); err != nil {
+		// Log the error, but don't fail the command
+		slog.Warn("could not write transcript", "err", err)
+	}
+
+	return nil
+}
+
+// getMTAPIKey returns the machine translation API key. PONS text translation
+// falls back on the dictionary API key.
+func getMTAPIKey() string {
+	if config.MTAPIKey == "" && config.MTProvider == "pons" {
+		return getAPIKey()
+	}
+	return config.MTAPIKey
+}
+
+func postJSON(url string, payload any, headers map[string]string, result any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("could not marshal json: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := getHTTPClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("could not fetch translation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp)
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("could not read response body: %w", err)
+	}
+
+	if err := json.Unmarshal(respBody, result); err != nil {
+		return fmt.Errorf("could not unmarshal json: %w", err)
+	}
+	return nil
+}
+
+func translateWithPONS(text, source, target string) (string, error) {
+	payload := map[string]string{
+		"sourceLanguage": source,
+		"targetLanguage": target,
+		"text":           text,
+	}
+	var result struct {
+		Text string `json:"text"`
+	}
+	err := postJSON(ponsTextTranslationURL+"?locale=en", payload, map[string]string{"X-Secret": getMTAPIKey()}, &result)
+	return result.Text, err
+}
+
+func translateWithDeepL(text, source, target string) (string, error) {
+	url := deeplURL
+	if strings.HasSuffix(getMTAPIKey(), ":fx") {
+		// Keys of the free plan must use their own endpoint
+		url = deeplFreeURL
+	}
+	payload := map[string]any{
+		"text":        []string{text},
+		"source_lang": strings.ToUpper(source),
+		"target_lang": strings.ToUpper(target),
+	}
+	var result struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	if err := postJSON(url, payload, map[string]string{"Authorization": "DeepL-Auth-Key " + getMTAPIKey()}, &result); err != nil {
+		return "", err
+	}
+	if len(result.Translations) == 0 {
+		return "", errNoTranslation
+	}
+	return result.Translations[0].Text, nil
+}
+
+func translateWithLibreTranslate(text, source, target string) (string, error) {
+	url := config.MTURL
+	if url == "" {
+		url = defaultLibreTranslateURL
+	}
+	payload := map[string]string{
+		"q":       text,
+		"source":  source,
+		"target":  target,
+		"format":  "text",
+		"api_key": getMTAPIKey(),
+	}
+	var result struct {
+		TranslatedText string `json:"translatedText"`
+	}
+	err := postJSON(strings.TrimSuffix(url, "/")+"/translate", payload, nil, &result)
+	return result.TranslatedText, err
+}
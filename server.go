@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// runServer serves lookups over HTTP, so that other local tools can query
+// the dictionaries without knowing the API key.
+func runServer(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	listen := flags.String("listen", "localhost:8080", "address to listen on")
+	flags.Parse(args)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /translate", handleServeTranslate)
+	mux.HandleFunc("GET /dictionaries", handleServeDictionaries)
+	mux.HandleFunc("GET /metrics", handleServeMetrics)
+
+	color.New(color.FgYellow).Printf("Listening on %s\n", *listen)
+	return http.ListenAndServe(*listen, mux)
+}
+
+func handleServeTranslate(w http.ResponseWriter, r *http.Request) {
+	word := r.URL.Query().Get("q")
+	dict := r.URL.Query().Get("dict")
+	if word == "" || dict == "" {
+		writeServeError(w, http.StatusBadRequest, "missing q or dict parameter")
+		return
+	}
+
+	start := time.Now()
+	translations, cached, err := lookupTranslation(word, dict)
+	if err != nil {
+		if errors.Is(err, errNoTranslation) {
+			serverMetrics.recordLookup("not_found", time.Since(start))
+			writeServeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		serverMetrics.recordLookup("error", time.Since(start))
+		writeServeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	serverMetrics.recordLookup("found", time.Since(start))
+
+	if err := addSearchHistory(word, dict, cached); err != nil {
+		// Log the error, but don't fail the request
+		slog.Warn("could not add search history", "err", err)
+	}
+
+	writeServeJSON(w, http.StatusOK, translations)
+}
+
+func handleServeDictionaries(w http.ResponseWriter, r *http.Request) {
+	dictionaries, err := getDictionaries()
+	if err != nil {
+		writeServeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	writeServeJSON(w, http.StatusOK, dictionaries)
+}
+
+func writeServeError(w http.ResponseWriter, status int, message string) {
+	writeServeJSON(w, status, map[string]string{"error": message})
+}
+
+func writeServeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("could not write response", "err", err)
+	}
+}
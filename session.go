@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// maxSessionResults is the number of last results a session keeps.
+const maxSessionResults = 10
+
+// session is the state of the REPL saved by .session save: the dictionary
+// and its direction, the output settings and the filters, and the last
+// results, shown again once loaded.
+type session struct {
+	Dict         string         `json:"dict"`
+	SourceLang   string         `json:"source_lang,omitempty"`
+	OutputFormat string         `json:"output_format"`
+	ShowExamples bool           `json:"show_examples"`
+	Exact        bool           `json:"exact"`
+	Sort         string         `json:"sort"`
+	QueryCase    string         `json:"query_case"`
+	Results      []lookupResult `json:"results"`
+}
+
+func getSession() session {
+	lastResultMu.Lock()
+	results := append([]lookupResult(nil), recentResults...)
+	lastResultMu.Unlock()
+
+	return session{
+		Dict:         currentDict,
+		SourceLang:   sourceLang,
+		OutputFormat: outputFormat,
+		ShowExamples: config.ShowExamples,
+		Exact:        config.Exact,
+		Sort:         config.Sort,
+		QueryCase:    config.QueryCase,
+		Results:      results,
+	}
+}
+
+// restoreSession restores the state of a session, for the REPL only: the
+// configuration file is left as it is.
+func restoreSession(s session) {
+	setCurrentDict(s.Dict)
+	sourceLang = s.SourceLang
+	outputFormat = s.OutputFormat
+	config.ShowExamples = s.ShowExamples
+	config.Exact = s.Exact
+	config.Sort = s.Sort
+	config.QueryCase = s.QueryCase
+
+	lastResultMu.Lock()
+	recentResults = s.Results
+	lastResult = nil
+	if len(s.Results) > 0 {
+		lastResult = &s.Results[len(s.Results)-1]
+	}
+	lastResultMu.Unlock()
+	shownSection.Store(0)
+}
+
+func saveSession(name string) error {
+	data, err := json.Marshal(getSession())
+	if err != nil {
+		return fmt.Errorf("could not marshal session: %w", err)
+	}
+	_, err = db.Exec(`
+		INSERT INTO sessions(name, data, date) VALUES(?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET data = excluded.data, date = excluded.date
+	`, name, string(data), time.Now())
+	if err != nil {
+		return fmt.Errorf("could not save session: %w", err)
+	}
+	return nil
+}
+
+func loadSession(name string) (session, error) {
+	var data string
+	err := db.QueryRow("SELECT data FROM sessions WHERE name = ?", name).Scan(&data)
+	if err == sql.ErrNoRows {
+		return session{}, fmt.Errorf("no session named %s", name)
+	}
+	if err != nil {
+		return session{}, fmt.Errorf("could not query session: %w", err)
+	}
+
+	var s session
+	if err := json.Unmarshal([]byte(data), &s); err != nil {
+		return session{}, fmt.Errorf("could not unmarshal session: %w", err)
+	}
+	return s, nil
+}
+
+func deleteSession(name string) error {
+	result, err := db.Exec("DELETE FROM sessions WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("could not delete session: %w", err)
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return fmt.Errorf("no session named %s", name)
+	}
+	return nil
+}
+
+func listSessions() error {
+	rows, err := db.Query("SELECT name, data, date FROM sessions ORDER BY date DESC")
+	if err != nil {
+		return fmt.Errorf("could not query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Session", "Dictionary", "Results", "Saved"})
+	count := 0
+	for rows.Next() {
+		var name, data string
+		var date time.Time
+		if err := rows.Scan(&name, &data, &date); err != nil {
+			return fmt.Errorf("could not scan row: %w", err)
+		}
+		var s session
+		if err := json.Unmarshal([]byte(data), &s); err != nil {
+			return fmt.Errorf("could not unmarshal session: %w", err)
+		}
+		t.AppendRow(table.Row{name, s.Dict, len(s.Results), formatRelativeTime(date, time.Now())})
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if count == 0 {
+		printNotice("No sessions\n")
+		return nil
+	}
+	t.Render()
+	return nil
+}
+
+// handleSessionCommand saves the state of the REPL under a name, loads it
+// back, deletes it, or lists the sessions without arguments.
+func handleSessionCommand(args []string) error {
+	if len(args) == 0 {
+		return listSessions()
+	}
+	if len(args) != 2 || (args[0] != "save" && args[0] != "load" && args[0] != "delete") {
+		return fmt.Errorf("usage: .session [save|load|delete <name>]")
+	}
+
+	name := args[1]
+	switch args[0] {
+	case "save":
+		if err := saveSession(name); err != nil {
+			return err
+		}
+		printNotice("Session %s saved\n", name)
+	case "load":
+		s, err := loadSession(name)
+		if err != nil {
+			return err
+		}
+		restoreSession(s)
+		// The last results are shown again, as they were left
+		for _, result := range s.Results {
+			var buf bytes.Buffer
+			renderTranslation(&buf, result.Translations, result.Dict, result.Word)
+			fmt.Fprint(color.Output, buf.String())
+		}
+		printNotice("Session %s loaded\n", name)
+	case "delete":
+		if err := deleteSession(name); err != nil {
+			return err
+		}
+		printNotice("Session %s deleted\n", name)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// sortOrders are the orders of the entries of results: as PONS sends them,
+// alphabetical, or grouped by word class in alphabetical order.
+var sortOrders = []string{"relevance", "alpha", "wordclass"}
+
+// sortTranslations returns a copy of translations with the entries in the
+// order of the sort setting. In alphabetical order, the rows of each
+// entry are sorted too.
+func sortTranslations(translations TranslationResponse) TranslationResponse {
+	if config.Sort != "alpha" && config.Sort != "wordclass" {
+		return translations
+	}
+
+	sorted := make(TranslationResponse, len(translations))
+	for i, lang := range translations {
+		collator := collate.New(language.Make(lang.Lang), collate.IgnoreCase)
+		compare := func(a, b string) int {
+			return collator.CompareString(normalizeHeadword(a), normalizeHeadword(b))
+		}
+
+		hits := make([]Hit, len(lang.Hits))
+		for j, hit := range lang.Hits {
+			hit.Roms = slices.Clone(hit.Roms)
+			if config.Sort == "alpha" {
+				for k, rom := range hit.Roms {
+					hit.Roms[k].Arabs = sortArabs(rom.Arabs, compare)
+				}
+				slices.SortStableFunc(hit.Roms, func(a, b Rom) int {
+					return compare(a.Headword, b.Headword)
+				})
+			} else {
+				slices.SortStableFunc(hit.Roms, func(a, b Rom) int {
+					return strings.Compare(a.WordClass, b.WordClass)
+				})
+			}
+			hits[j] = hit
+		}
+		if config.Sort == "alpha" {
+			slices.SortStableFunc(hits, func(a, b Hit) int {
+				return compare(getHitHeadword(a), getHitHeadword(b))
+			})
+		}
+		lang.Hits = hits
+		sorted[i] = lang
+	}
+	return sorted
+}
+
+// sortArabs returns a copy of arabs with their rows in alphabetical order
+// of their source.
+func sortArabs(arabs []Arab, compare func(a, b string) int) []Arab {
+	sorted := make([]Arab, len(arabs))
+	for i, arab := range arabs {
+		arab.Translations = slices.Clone(arab.Translations)
+		slices.SortStableFunc(arab.Translations, func(a, b Translation) int {
+			return compare(a.Source, b.Source)
+		})
+		sorted[i] = arab
+	}
+	return sorted
+}
+
+// getHitHeadword returns the headword a hit is sorted by.
+func getHitHeadword(hit Hit) string {
+	if len(hit.Roms) > 0 {
+		return hit.Roms[0].Headword
+	}
+	return hit.Source
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Languages in which accents are often left out when typing
+var accentedLanguages = []string{"es", "fr", "it", "pt"}
+
+// Transcriptions of German letters on keyboards without them
+var germanTranscriptions = [][2]string{{"ae", "ä"}, {"oe", "ö"}, {"ue", "ü"}, {"ss", "ß"}}
+
+// getSpellingVariants returns the alternative spellings of word to try when
+// it has no entry: case variants, German letters from their transcription
+// and, for languages with accents, the word without them.
+func getSpellingVariants(word, dict string) []string {
+	langs := []string{}
+	if len(dict) == 4 {
+		langs = []string{dict[:2], dict[2:]}
+	}
+
+	candidates := []string{strings.ToLower(word), capitalize(word)}
+
+	if slices.Contains(langs, "de") {
+		lower := strings.ToLower(word)
+		replaced := lower
+		for _, transcription := range germanTranscriptions {
+			if strings.Contains(lower, transcription[0]) {
+				candidates = append(candidates, strings.ReplaceAll(lower, transcription[0], transcription[1]))
+				replaced = strings.ReplaceAll(replaced, transcription[0], transcription[1])
+			}
+		}
+		candidates = append(candidates, replaced, capitalize(replaced))
+	}
+
+	for _, lang := range langs {
+		if slices.Contains(accentedLanguages, lang) {
+			candidates = append(candidates, stripAccents(word))
+			break
+		}
+	}
+
+	var variants []string
+	for _, candidate := range candidates {
+		if candidate != word && !slices.Contains(variants, candidate) {
+			variants = append(variants, candidate)
+		}
+	}
+	return variants
+}
+
+func stripAccents(word string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	stripped, _, err := transform.String(t, word)
+	if err != nil {
+		return word
+	}
+	return stripped
+}
+
+// lookupSpellingVariants looks up the alternative spellings of word, and
+// returns the first one which has an entry.
+func lookupSpellingVariants(word, dict string) (string, TranslationResponse, bool) {
+	for _, variant := range getSpellingVariants(word, dict) {
+		translations, err := getTranslation(variant, dict)
+		if err == nil && len(translations) > 0 {
+			return variant, translations, true
+		}
+	}
+	return "", nil, false
+}
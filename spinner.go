@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// spinnerDelay is how long a request must take for the spinner to show, so
+// that fast ones don't make it flicker.
+const spinnerDelay = 200 * time.Millisecond
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerEnabled shows a spinner on stderr during the requests, when it's a
+// terminal and the output is meant for a human.
+var spinnerEnabled bool
+
+var spinner struct {
+	mu      sync.Mutex
+	pending int
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// startSpinner shows the spinner until the returned function is called.
+// Concurrent requests share the same spinner.
+func startSpinner() func() {
+	// Screen readers would read each frame
+	if !spinnerEnabled || config.Accessible {
+		return func() {}
+	}
+
+	spinner.mu.Lock()
+	spinner.pending++
+	if spinner.pending == 1 {
+		spinner.stop = make(chan struct{})
+		spinner.done = make(chan struct{})
+		go spin(spinner.stop, spinner.done)
+	}
+	spinner.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			spinner.mu.Lock()
+			defer spinner.mu.Unlock()
+			spinner.pending--
+			if spinner.pending == 0 {
+				close(spinner.stop)
+				<-spinner.done
+			}
+		})
+	}
+}
+
+func spin(stop, done chan struct{}) {
+	defer close(done)
+
+	select {
+	case <-stop:
+		return
+	case <-time.After(spinnerDelay):
+	}
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	faint := color.New(color.Faint)
+	for i := 0; ; i++ {
+		fmt.Fprintf(color.Error, "\r%s", faint.Sprintf("%s Fetching…", spinnerFrames[i%len(spinnerFrames)]))
+		select {
+		case <-stop:
+			// Clear the line, with spaces where erasing isn't supported
+			if virtualTerminal {
+				fmt.Fprint(color.Error, "\r\033[K")
+			} else {
+				fmt.Fprintf(color.Error, "\r%s\r", strings.Repeat(" ", 12))
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/chzyer/readline"
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// maxSuggestionTerms is the number of recent searches suggestions are
+// taken from.
+const maxSuggestionTerms = 1000
+
+// suggester suggests the most recent search starting with the line typed at
+// the REPL prompt, shown dimmed after the cursor and accepted with the right
+// arrow.
+type suggester struct {
+	mu          sync.Mutex
+	terms       []string
+	promptWidth int
+
+	// suggestion is the rest of the search suggested for line
+	line       []rune
+	suggestion []rune
+}
+
+var replSuggester = &suggester{}
+
+// prepare loads the recent searches before the prompt is shown, as readline
+// asks for suggestions from its own goroutine at each key press.
+func (s *suggester) prepare(prompt string) {
+	var terms []string
+	if config.Autosuggest && !config.Accessible && !color.NoColor && db != nil {
+		var err error
+		if terms, err = getRecentSearches(maxSuggestionTerms); err != nil {
+			// Log the error, but don't fail the prompt
+			slog.Warn("could not load suggestions", "err", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.terms = terms
+	s.promptWidth = text.StringWidthWithoutEscSequences(prompt)
+	s.line, s.suggestion = nil, nil
+}
+
+// getRecentSearches returns the distinct terms found by the last searches,
+// the most recent first.
+func getRecentSearches(limit int) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT searched_term FROM search_history
+		WHERE found = 1
+		GROUP BY searched_term
+		ORDER BY MAX(date) DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("could not query search history: %w", err)
+	}
+	defer rows.Close()
+
+	var terms []string
+	for rows.Next() {
+		var term string
+		if err := rows.Scan(&term); err != nil {
+			return nil, fmt.Errorf("could not scan row: %w", err)
+		}
+		terms = append(terms, term)
+	}
+	return terms, rows.Err()
+}
+
+// suggest returns the rest of the most recent search starting with line,
+// which must fit on the terminal line.
+func (s *suggester) suggest(line []rune) []rune {
+	typed := string(line)
+	if strings.TrimSpace(typed) == "" || strings.HasPrefix(typed, ".") || strings.ContainsRune(typed, '\n') {
+		return nil
+	}
+	for _, term := range s.terms {
+		if len(term) <= len(typed) || !strings.HasPrefix(term, typed) {
+			continue
+		}
+		rest := []rune(term[len(typed):])
+		width := s.promptWidth + text.StringWidthWithoutEscSequences(term)
+		if screenWidth := readline.GetScreenWidth(); screenWidth > 0 && width >= screenWidth {
+			return nil
+		}
+		return rest
+	}
+	return nil
+}
+
+// Paint implements readline.Painter, adding the suggestion after the line
+// when the cursor is at its end.
+func (s *suggester) Paint(line []rune, pos int) []rune {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.line, s.suggestion = nil, nil
+	if !atReplPrompt.Load() || pos != len(line) {
+		return line
+	}
+	suggestion := s.suggest(line)
+	if suggestion == nil {
+		return line
+	}
+	s.line, s.suggestion = append([]rune(nil), line...), suggestion
+
+	// The cursor is moved back before the suggestion
+	painted := append([]rune(nil), line...)
+	painted = append(painted, []rune(color.New(color.Faint).Sprint(string(suggestion)))...)
+	painted = append(painted, []rune(fmt.Sprintf("\033[%dD", text.StringWidthWithoutEscSequences(string(suggestion))))...)
+	return painted
+}
+
+// accept replaces the line by the suggested search, if one is shown.
+func (s *suggester) accept() bool {
+	s.mu.Lock()
+	line, suggestion := s.line, s.suggestion
+	s.mu.Unlock()
+	if suggestion == nil {
+		return false
+	}
+	replReadline.Operation.SetBuffer(string(line) + string(suggestion))
+	return true
+}
+
+// filterInputRune is the readline input filter of the REPL, accepting
+// suggestions, running the keybindings and returning on resize.
+func filterInputRune(r rune) (rune, bool) {
+	if r == readline.CharForward && atReplPrompt.Load() && replSuggester.accept() {
+		return r, false
+	}
+	if r == resizeRune {
+		return filterResize()
+	}
+	return filterKeybinding(r)
+}
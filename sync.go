@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// syncData is the content of the sync file.
+type syncData struct {
+	Version int           `json:"version"`
+	Updated time.Time     `json:"updated"`
+	History []syncHistory `json:"history"`
+}
+
+type syncHistory struct {
+	Term string    `json:"term"`
+	Dict string    `json:"dict"`
+	Date time.Time `json:"date"`
+}
+
+// key identifies a search, the same search being made at the same time on
+// two machines being unlikely.
+func (h syncHistory) key() string {
+	return h.Term + "\x00" + h.Dict + "\x00" + h.Date.UTC().Format(time.RFC3339Nano)
+}
+
+// handleSyncCommand merges the search history with the sync file: the
+// searches made on other machines are added to the history, and the ones
+// made here to the file.
+func handleSyncCommand() error {
+	if config.SyncFile == "" {
+		return fmt.Errorf("no sync file, set one with .set sync_file <path>")
+	}
+
+	remote, err := readSyncFile(config.SyncFile)
+	if err != nil {
+		return err
+	}
+
+	local, err := getSyncHistory()
+	if err != nil {
+		return err
+	}
+
+	imported := missingFrom(local, remote.History)
+	if err := importSyncHistory(imported); err != nil {
+		return err
+	}
+
+	exported := missingFrom(remote.History, local)
+	merged := syncData{Version: 1, Updated: time.Now(), History: append(remote.History, exported...)}
+	if err := writeSyncFile(config.SyncFile, merged); err != nil {
+		return err
+	}
+
+	color.New(color.FgGreen).Printf("Synced with %s: %d searches imported, %d exported\n", config.SyncFile, len(imported), len(exported))
+	return nil
+}
+
+// missingFrom returns the entries of history which entries lacks, each
+// once.
+func missingFrom(entries, history []syncHistory) []syncHistory {
+	known := map[string]bool{}
+	for _, h := range entries {
+		known[h.key()] = true
+	}
+	var missing []syncHistory
+	for _, h := range history {
+		if !known[h.key()] {
+			known[h.key()] = true
+			missing = append(missing, h)
+		}
+	}
+	return missing
+}
+
+func readSyncFile(path string) (syncData, error) {
+	var data syncData
+	body, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return data, nil
+	}
+	if err != nil {
+		return data, fmt.Errorf("could not read sync file: %w", err)
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return data, fmt.Errorf("could not unmarshal sync file: %w", err)
+	}
+	if data.Version > 1 {
+		return data, fmt.Errorf("sync file version %d is newer than this version of pons-cli supports", data.Version)
+	}
+	return data, nil
+}
+
+// writeSyncFile replaces the sync file at once, so that the sync tool never
+// picks up a partly written one.
+func writeSyncFile(path string, data syncData) error {
+	body, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal sync file: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".pons-cli-sync-*")
+	if err != nil {
+		return fmt.Errorf("could not write sync file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not write sync file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("could not write sync file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("could not write sync file: %w", err)
+	}
+	return nil
+}
+
+func getSyncHistory() ([]syncHistory, error) {
+	rows, err := db.Query("SELECT searched_term, dict, date FROM search_history WHERE found = 1 ORDER BY date ASC")
+	if err != nil {
+		return nil, fmt.Errorf("could not query search history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []syncHistory
+	for rows.Next() {
+		var h syncHistory
+		if err := rows.Scan(&h.Term, &h.Dict, &h.Date); err != nil {
+			return nil, fmt.Errorf("could not scan row: %w", err)
+		}
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
+
+func importSyncHistory(history []syncHistory) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin import: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, h := range history {
+		if _, err := tx.Exec("INSERT INTO search_history(searched_term, dict, date) VALUES(?, ?, ?)", h.Term, h.Dict, h.Date); err != nil {
+			return fmt.Errorf("could not import search history: %w", err)
+		}
+	}
+	return tx.Commit()
+}
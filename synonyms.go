@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+const (
+	openThesaurusURL = "https://www.openthesaurus.de/synonyme/search"
+	datamuseURL      = "https://api.datamuse.com/words"
+)
+
+// thesaurusSources are the sources synonyms can be looked up in.
+var thesaurusSources = []string{"openthesaurus", "datamuse"}
+
+// defaultThesauruses are the sources of the languages the [thesaurus]
+// section of the config file doesn't set.
+var defaultThesauruses = map[string]string{
+	"de": "openthesaurus",
+	"en": "datamuse",
+}
+
+type openThesaurusResponse struct {
+	Synsets []struct {
+		Terms []struct {
+			Term string `json:"term"`
+		} `json:"terms"`
+	} `json:"synsets"`
+}
+
+type datamuseResponse []struct {
+	Word string `json:"word"`
+}
+
+// validateThesauruses checks the sources of the [thesaurus] section.
+func validateThesauruses(thesauruses map[string]string) error {
+	for lang, source := range thesauruses {
+		if !slices.Contains(thesaurusSources, source) {
+			return fmt.Errorf("unknown source %s for %s, expected one of %s", source, lang, strings.Join(thesaurusSources, ", "))
+		}
+	}
+	return nil
+}
+
+// getThesaurus returns the source of the synonyms of lang.
+func getThesaurus(lang string) (string, bool) {
+	if source, ok := config.Thesaurus[lang]; ok {
+		return source, true
+	}
+	source, ok := defaultThesauruses[lang]
+	return source, ok
+}
+
+// formatThesauruses lists the sources of the languages having a thesaurus,
+// sorted by language.
+func formatThesauruses() string {
+	thesauruses := maps.Clone(defaultThesauruses)
+	maps.Copy(thesauruses, config.Thesaurus)
+	langs := slices.Sorted(maps.Keys(thesauruses))
+	sources := make([]string, len(langs))
+	for i, lang := range langs {
+		sources[i] = fmt.Sprintf("%s=%s", lang, thesauruses[lang])
+	}
+	return strings.Join(sources, ", ")
+}
+
+// getSynonymGroups returns the synonyms of word in lang, grouped by meaning
+// when the source tells them apart.
+func getSynonymGroups(word, lang string) ([][]string, error) {
+	source, ok := getThesaurus(lang)
+	if !ok {
+		return nil, fmt.Errorf("no thesaurus for %s, set one in the [thesaurus] section of %s", getLanguageName(lang), getConfigFile())
+	}
+
+	key := getTranslationCacheKey(word, lang)
+	var groups [][]string
+	switch source {
+	case "openthesaurus":
+		body, err := fetchCached("synonyms_"+source+"_"+key+".json", openThesaurusURL+"?format=application/json&q="+url.QueryEscape(word))
+		if err != nil {
+			return nil, err
+		}
+		var response openThesaurusResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("could not unmarshal json: %w", err)
+		}
+		for _, synset := range response.Synsets {
+			var group []string
+			for _, term := range synset.Terms {
+				group = append(group, term.Term)
+			}
+			groups = append(groups, group)
+		}
+	case "datamuse":
+		body, err := fetchCached("synonyms_"+source+"_"+key+".json", datamuseURL+"?rel_syn="+url.QueryEscape(word))
+		if err != nil {
+			return nil, err
+		}
+		var response datamuseResponse
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("could not unmarshal json: %w", err)
+		}
+		var group []string
+		for _, w := range response {
+			group = append(group, w.Word)
+		}
+		groups = append(groups, group)
+	}
+
+	// The word itself is among the terms of its synsets
+	var synonyms [][]string
+	for _, group := range groups {
+		group = slices.DeleteFunc(group, func(s string) bool { return strings.EqualFold(s, word) })
+		if len(group) > 0 {
+			synonyms = append(synonyms, group)
+		}
+	}
+	if len(synonyms) == 0 {
+		return nil, fmt.Errorf("no synonyms found for %s", word)
+	}
+	return synonyms, nil
+}
+
+// getSynonyms returns the distinct synonyms of word in lang, sorted, like
+// the wrong choices a quiz needs.
+func getSynonyms(word, lang string) ([]string, error) {
+	groups, err := getSynonymGroups(word, lang)
+	if err != nil {
+		return nil, err
+	}
+	var synonyms []string
+	for _, group := range groups {
+		for _, synonym := range group {
+			if !slices.Contains(synonyms, synonym) {
+				synonyms = append(synonyms, synonym)
+			}
+		}
+	}
+	sort.Strings(synonyms)
+	return synonyms, nil
+}
+
+// handleSynCommand shows the synonyms of a word in the source language of
+// the current dictionary, or of the first entry of the last result without
+// a word.
+func handleSynCommand(args []string) error {
+	var word, lang string
+	if len(args) == 0 {
+		result := getLastResult()
+		if result == nil {
+			return fmt.Errorf("usage: .syn <word>")
+		}
+		entry, rom, ok := getEntry(getNumberedEntries(result.Translations), 1)
+		if !ok {
+			return fmt.Errorf("no previous lookup")
+		}
+		word = getPlainHeadword(rom)
+		lang = entry[0].Lang
+	} else {
+		if currentDict == "" {
+			return fmt.Errorf("no dictionary selected. Use .dict <key> to select one")
+		}
+		word = strings.Join(args, " ")
+		if lang = getSourceLang(currentDict); lang == "" {
+			lang = currentDict[:2]
+		}
+	}
+
+	groups, err := getSynonymGroups(word, lang)
+	if err != nil {
+		return err
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetStyle(getTableStyle())
+	t.AppendHeader(table.Row{"Synonyms of " + word})
+	for _, group := range groups {
+		t.AppendRow(table.Row{wrapCell(strings.Join(group, ", "), getTermWidth()-getTableOverhead())})
+	}
+	t.Render()
+	return nil
+}
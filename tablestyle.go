@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// tableStyles are the bordered styles of table_style, "none" being the
+// default borderless one.
+var tableStyles = map[string]table.Style{
+	"light":   table.StyleLight,
+	"rounded": table.StyleRounded,
+	"double":  table.StyleDouble,
+	"bold":    table.StyleBold,
+	"ascii":   table.StyleDefault,
+}
+
+// getTableStyle returns the style of the translation tables, after
+// table_style, column_separator, row_separator, cell_padding and
+// table_header.
+func getTableStyle() table.Style {
+	style, bordered := tableStyles[config.TableStyle]
+	if !bordered {
+		// The borderless style still draws the separators asked for
+		style = table.Style{Name: "NoBorders", Box: table.StyleBoxLight}
+	}
+	style.Options = table.Options{
+		DrawBorder:      bordered,
+		SeparateColumns: config.ColumnSeparator,
+		SeparateRows:    config.RowSeparator,
+		SeparateHeader:  config.TableHeader,
+	}
+	padding := strings.Repeat(" ", config.CellPadding)
+	style.Box.PaddingLeft, style.Box.PaddingRight = padding, padding
+	return style
+}
+
+// getTableOverhead returns the width the borders, the separator and the
+// padding of a two columns table take.
+func getTableOverhead() int {
+	overhead := 4 * config.CellPadding
+	if _, bordered := tableStyles[config.TableStyle]; bordered {
+		overhead += 2
+	}
+	if config.ColumnSeparator {
+		overhead++
+	}
+	return overhead
+}
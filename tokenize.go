@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// splitCommands splits a line of input into the commands separated by ";",
+// e.g. ".dict deen; Haus". Semicolons inside double quotes or escaped with a
+// backslash don't separate commands.
+func splitCommands(line string) []string {
+	var commands []string
+	var sb strings.Builder
+	quoted := false
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case r == ';' && !quoted:
+			commands = append(commands, sb.String())
+			sb.Reset()
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return append(commands, sb.String())
+}
+
+// tokenize splits a command into its words, separated by spaces. Double
+// quotes group words, e.g. `.translate "good morning"`, and a backslash
+// escapes the next character. Single quotes are left alone, as they are
+// apostrophes in words like "aujourd'hui".
+func tokenize(command string) ([]string, error) {
+	var tokens []string
+	var sb strings.Builder
+	quoted := false
+	inToken := false
+	escaped := false
+	for _, r := range command {
+		switch {
+		case escaped:
+			sb.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+			inToken = true
+		case r == '"':
+			quoted = !quoted
+			inToken = true
+		case quoted:
+			sb.WriteRune(r)
+		case r == ' ' || r == '\t':
+			if inToken {
+				tokens = append(tokens, sb.String())
+				sb.Reset()
+				inToken = false
+			}
+		default:
+			sb.WriteRune(r)
+			inToken = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if escaped {
+		return nil, fmt.Errorf("nothing to escape at the end of the command")
+	}
+	if inToken {
+		tokens = append(tokens, sb.String())
+	}
+	return tokens, nil
+}
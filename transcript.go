@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// sessionTranscript is the transcript file opened with --log-session. When
+// set, it takes precedence over the transcript config variable.
+var sessionTranscript string
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+func startSessionTranscript() error {
+	file, err := getStateFile("session-" + time.Now().Format("20060102-150405") + ".txt")
+	if err != nil {
+		return err
+	}
+	sessionTranscript = file
+	color.New(color.FgYellow).Printf("Logging session to %s\n", sessionTranscript)
+	return nil
+}
+
+func getTranscriptFile() string {
+	if sessionTranscript != "" {
+		return sessionTranscript
+	}
+	return config.Transcript
+}
+
+// appendTranscript writes a query and its rendered result to the transcript
+// file, without the terminal color codes.
+func appendTranscript(word, dict, rendered string) error {
+	path := getTranscriptFile()
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open transcript file: %w", err)
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "[%s] %s >>> %s\n%s\n", time.Now().Format("2006-01-02 15:04:05"), dict, word, ansiEscape.ReplaceAllString(rendered, ""))
+	return err
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/gosimple/unidecode"
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// Languages written in a non-Latin script which can be romanized. Chinese is
+// romanized to pinyin, without tones.
+var transliteratedLanguages = []string{"ar", "bg", "el", "ru", "uk", "zh"}
+
+// applyTransliteration adds, when the transliterate setting is on, a
+// romanization line under the cells written in a non-Latin script.
+func applyTransliteration(rows []table.Row, langs [2]string) []table.Row {
+	if !config.Transliterate {
+		return rows
+	}
+
+	transliterated := make([]table.Row, len(rows))
+	for i, row := range rows {
+		transliterated[i] = slices.Clone(row)
+		for col, lang := range langs {
+			if !slices.Contains(transliteratedLanguages, lang) {
+				continue
+			}
+			cell := fmt.Sprint(row[col])
+			romanized := strings.Join(strings.Fields(unidecode.Unidecode(ansiEscape.ReplaceAllString(cell, ""))), " ")
+			if romanized != "" {
+				transliterated[i][col] = cell + "\n" + color.New(color.Faint).Sprint(romanized)
+			}
+		}
+	}
+	return transliterated
+}
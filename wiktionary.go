@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const wiktionaryDefinitionURL = "https://en.wiktionary.org/api/rest_v1/page/definition/"
+
+// Languages whose words are defined, in English, by the English Wiktionary
+var wiktionaryLanguages = map[string]string{
+	"de": "German",
+	"es": "Spanish",
+	"fr": "French",
+	"it": "Italian",
+	"nl": "Dutch",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"sv": "Swedish",
+	"tr": "Turkish",
+}
+
+type wiktionaryResponse map[string][]struct {
+	PartOfSpeech string `json:"partOfSpeech"`
+	Definitions  []struct {
+		Definition string   `json:"definition"`
+		Examples   []string `json:"examples"`
+	} `json:"definitions"`
+}
+
+// wiktionaryProvider looks words up in the English Wiktionary, whose
+// definitions serve as translations into English.
+type wiktionaryProvider struct{}
+
+func (wiktionaryProvider) Dictionaries() ([]Dictionary, error) {
+	langs := make([]string, 0, len(wiktionaryLanguages))
+	for lang := range wiktionaryLanguages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	var dictionaries []Dictionary
+	for _, lang := range langs {
+		dictionaries = append(dictionaries, Dictionary{
+			Key:         lang + "en",
+			SimpleLabel: wiktionaryLanguages[lang] + " » English (Wiktionary)",
+			Languages:   []string{lang, "en"},
+		})
+	}
+	return dictionaries, nil
+}
+
+func (wiktionaryProvider) Lookup(word, dict string) (TranslationResponse, bool, error) {
+	lang := strings.TrimSuffix(dict, "en")
+	if _, ok := wiktionaryLanguages[lang]; !ok || len(dict) != 4 {
+		return nil, false, fmt.Errorf("unknown dictionary key: %s", dict)
+	}
+
+	body, cached, err := fetchWiktionary(word)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var entries wiktionaryResponse
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, false, fmt.Errorf("could not unmarshal json: %w", err)
+	}
+
+	if len(entries[lang]) == 0 {
+		return nil, false, errNoTranslation
+	}
+
+	var roms []Rom
+	for _, entry := range entries[lang] {
+		arab := Arab{Header: entry.PartOfSpeech}
+		for _, definition := range entry.Definitions {
+			if definition.Definition == "" {
+				continue
+			}
+			arab.Translations = append(arab.Translations, Translation{Source: word, Target: definition.Definition})
+			for _, example := range definition.Examples {
+				arab.Translations = append(arab.Translations, Translation{Source: example})
+			}
+		}
+		roms = append(roms, Rom{Headword: word, WordClass: strings.ToLower(entry.PartOfSpeech), Arabs: []Arab{arab}})
+	}
+
+	return TranslationResponse{{Lang: lang, Hits: []Hit{{Roms: roms}}}}, cached, nil
+}
+
+func fetchWiktionary(word string) ([]byte, bool, error) {
+	return fetchCachedBody("wiktionary_"+getTranslationCacheKey(word, "")+".json", wiktionaryDefinitionURL+url.PathEscape(word))
+}
+
+// fetchWiktionaryPage fetches the page of word from a Wiktionary REST
+// endpoint, cached under prefix like translations.
+func fetchWiktionaryPage(endpoint, prefix, ext, word string) ([]byte, error) {
+	return fetchCached(prefix+getTranslationCacheKey(word, "")+ext, endpoint+url.PathEscape(word))
+}
+
+// fetchCached fetches rawURL from other sources than PONS, cached in the
+// cache file named name like translations. Missing pages fail with "no
+// translation found".
+func fetchCached(name, rawURL string) ([]byte, error) {
+	body, _, err := fetchCachedBody(name, rawURL)
+	return body, err
+}
+
+// fetchCachedBody fetches rawURL like fetchCached, and tells whether the
+// body was served from the cache.
+func fetchCachedBody(name, rawURL string) ([]byte, bool, error) {
+	cacheFile, err := getCacheFile(name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	cacheTTL := time.Duration(config.CacheTTL) * time.Second
+	if isCacheValid(cacheFile, cacheTTL) {
+		body, err := readCacheFile(cacheFile)
+		if err != nil {
+			return nil, false, err
+		}
+		recordCacheHit(rawURL)
+		return body, true, nil
+	}
+
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not create request: %w", err)
+	}
+
+	resp, err := getHTTPClient().Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, errNoTranslation
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, newAPIError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not read response body: %w", err)
+	}
+
+	// Write to cache
+	if err := writeCacheFile(cacheFile, body); err != nil {
+		// Log this error, but don't fail the command
+		slog.Warn("could not write cache file", "err", err)
+	}
+
+	return body, false, nil
+}
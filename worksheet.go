@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// worksheetTranslations bounds the translations given for each word of a
+// worksheet.
+const worksheetTranslations = 3
+
+// worksheetBlank is the line left for students to write on in a blanked
+// column.
+const worksheetBlank = "__________________"
+
+type worksheetRow struct {
+	Word        string
+	Translation string
+}
+
+// worksheetFilter selects the saved words of a worksheet: those of a deck,
+// if not nil, and of a tag, if not empty.
+type worksheetFilter struct {
+	Deck *string
+	Tag  string
+}
+
+// getWorksheetRows returns the words saved with .save in dict and matching
+// filter, with their first translations, taken from the back of their
+// flashcard.
+func getWorksheetRows(dict string, filter worksheetFilter) ([]worksheetRow, error) {
+	query := "SELECT word, back FROM flashcards WHERE kind = 'word' AND dict = ?"
+	args := []any{dict}
+	if filter.Deck != nil {
+		query += " AND deck = ?"
+		args = append(args, *filter.Deck)
+	}
+	if filter.Tag != "" {
+		// Tags are separated by spaces
+		query += " AND instr(' ' || tags || ' ', ' ' || ? || ' ') > 0"
+		args = append(args, filter.Tag)
+	}
+	rows, err := db.Query(query+" ORDER BY created", args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query flashcards: %w", err)
+	}
+	defer rows.Close()
+
+	var worksheet []worksheetRow
+	for rows.Next() {
+		var word, back string
+		if err := rows.Scan(&word, &back); err != nil {
+			return nil, fmt.Errorf("could not scan row: %w", err)
+		}
+		var targets []string
+		for _, line := range strings.Split(back, "\n") {
+			_, target, ok := strings.Cut(line, " — ")
+			if !ok || target == "" || strings.EqualFold(target, word) || containsFold(targets, target) {
+				continue
+			}
+			if targets = append(targets, target); len(targets) == worksheetTranslations {
+				break
+			}
+		}
+		worksheet = append(worksheet, worksheetRow{Word: word, Translation: strings.Join(targets, ", ")})
+	}
+	return worksheet, rows.Err()
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func handleExportWorksheet(args []string) error {
+	usage := fmt.Errorf("usage: .export worksheet <file> [--blank source|target] [--deck <name>] [--tag <tag>]")
+	if len(args) == 0 || len(args)%2 != 1 {
+		return usage
+	}
+	blank := ""
+	var filter worksheetFilter
+	for i := 1; i < len(args); i += 2 {
+		flag, value := args[i], args[i+1]
+		switch {
+		case flag == "--blank" && (value == "source" || value == "target"):
+			blank = value
+		case flag == "--deck":
+			deck := getDeck(value)
+			filter.Deck = &deck
+		case flag == "--tag":
+			filter.Tag = value
+		default:
+			return usage
+		}
+	}
+	if currentDict == "" {
+		return fmt.Errorf("no dictionary selected. Use .dict <key> to select one")
+	}
+	return exportWorksheet(currentDict, args[0], blank, filter)
+}
+
+// exportWorksheet writes a printable vocabulary sheet of the words saved in
+// dict and matching filter, as Markdown, HTML or LaTeX depending on the
+// extension of path. blank is the column left empty for students to fill
+// in, if any.
+func exportWorksheet(dict, path, blank string, filter worksheetFilter) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".md" && ext != ".html" && ext != ".htm" && ext != ".tex" {
+		return fmt.Errorf("unknown worksheet format %s, use .md, .html or .tex", ext)
+	}
+
+	rows, err := getWorksheetRows(dict, filter)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 && (filter.Deck != nil || filter.Tag != "") {
+		return fmt.Errorf("no words saved in %s match the deck or tag", dict)
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("no words saved in %s, use .save to save some", dict)
+	}
+	for i := range rows {
+		switch blank {
+		case "source":
+			rows[i].Word = ""
+		case "target":
+			rows[i].Translation = ""
+		}
+	}
+
+	header := [2]string{dict, ""}
+	if len(dict) == 4 {
+		header = [2]string{getLanguageName(dict[:2]), getLanguageName(dict[2:])}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create worksheet file: %w", err)
+	}
+	defer file.Close()
+
+	switch ext {
+	case ".md":
+		writeWorksheetMarkdown(file, header, rows)
+	case ".tex":
+		writeWorksheetLaTeX(file, header, rows)
+	default:
+		writeWorksheetHTML(file, header, rows)
+	}
+
+	color.New(color.FgGreen).Printf("%d words exported to %s\n", len(rows), path)
+	return nil
+}
+
+func writeWorksheetMarkdown(w io.Writer, header [2]string, rows []worksheetRow) {
+	escape := strings.NewReplacer("|", `\|`).Replace
+	fmt.Fprintf(w, "# Vocabulary\n\nName: %s\n\n", worksheetBlank)
+	fmt.Fprintf(w, "| %s | %s |\n|---|---|\n", header[0], header[1])
+	for _, row := range rows {
+		fmt.Fprintf(w, "| %s | %s |\n", orBlank(escape(row.Word)), orBlank(escape(row.Translation)))
+	}
+}
+
+// writeWorksheetHTML writes a standalone page, to print or save as PDF from
+// the browser.
+func writeWorksheetHTML(w io.Writer, header [2]string, rows []worksheetRow) {
+	io.WriteString(w, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Vocabulary</title>
+<style>
+body { font-family: sans-serif; margin: 2cm; }
+table { width: 100%; border-collapse: collapse; }
+th, td { border: 1px solid #444; padding: 0.5em; width: 50%; text-align: left; }
+tr { page-break-inside: avoid; }
+</style>
+</head>
+<body>
+<h1>Vocabulary</h1>
+`)
+	fmt.Fprintf(w, "<p>Name: %s</p>\n<table>\n<tr><th>%s</th><th>%s</th></tr>\n", worksheetBlank, html.EscapeString(header[0]), html.EscapeString(header[1]))
+	for _, row := range rows {
+		fmt.Fprintf(w, "<tr><td>%s</td><td>%s</td></tr>\n", html.EscapeString(row.Word), html.EscapeString(row.Translation))
+	}
+	io.WriteString(w, "</table>\n</body>\n</html>\n")
+}
+
+// writeWorksheetLaTeX writes a document for pdflatex or xelatex.
+func writeWorksheetLaTeX(w io.Writer, header [2]string, rows []worksheetRow) {
+	escape := strings.NewReplacer(`\`, `\textbackslash{}`, "&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`, "_", `\_`, "{", `\{`, "}", `\}`, "~", `\textasciitilde{}`, "^", `\textasciicircum{}`).Replace
+	io.WriteString(w, `\documentclass[a4paper]{article}
+\usepackage[utf8]{inputenc}
+\usepackage[T1]{fontenc}
+\usepackage[margin=2cm]{geometry}
+\usepackage{longtable}
+\renewcommand{\arraystretch}{1.8}
+\begin{document}
+\section*{Vocabulary}
+Name: \rule{6cm}{0.4pt}
+\begin{longtable}{|p{0.45\textwidth}|p{0.45\textwidth}|}
+\hline
+`)
+	fmt.Fprintf(w, "\\textbf{%s} & \\textbf{%s} \\\\\n\\hline\n", escape(header[0]), escape(header[1]))
+	for _, row := range rows {
+		fmt.Fprintf(w, "%s & %s \\\\\n\\hline\n", escape(row.Word), escape(row.Translation))
+	}
+	io.WriteString(w, "\\end{longtable}\n\\end{document}\n")
+}
+
+// orBlank returns the line to write on for a blanked cell.
+func orBlank(s string) string {
+	if s == "" {
+		return worksheetBlank
+	}
+	return s
+}
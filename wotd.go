@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// wordLists are lists of frequent words per language, one per line, for the
+// word of the day.
+//
+//go:embed wordlists/*.txt
+var wordLists embed.FS
+
+// getWordList returns the frequent words of the source language of dict.
+func getWordList(dict string) ([]string, error) {
+	if len(dict) < 2 {
+		return nil, fmt.Errorf("unknown dictionary key: %s", dict)
+	}
+	body, err := wordLists.ReadFile("wordlists/" + dict[:2] + ".txt")
+	if err != nil {
+		return nil, fmt.Errorf("no word list for %s, use .set wotd_source saved", dict[:2])
+	}
+
+	var words []string
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			words = append(words, line)
+		}
+	}
+	return words, nil
+}
+
+// getWordOfTheDay picks the word of the day of dict, which stays the same
+// all day long.
+func getWordOfTheDay(dict string, day time.Time) (string, error) {
+	var words []string
+	var err error
+	if config.WOTDSource == "saved" {
+		words, err = getSearchedTermsOf(dict)
+		if err == nil && len(words) == 0 {
+			err = fmt.Errorf("no words searched in %s", dict)
+		}
+	} else {
+		words, err = getWordList(dict)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(dict + day.Format("2006-01-02")))
+	return words[h.Sum32()%uint32(len(words))], nil
+}
+
+// showWordOfTheDay shows the entry of the word of the day, without adding
+// it to the search history.
+func showWordOfTheDay(dict string) error {
+	word, err := getWordOfTheDay(dict, time.Now())
+	if err != nil {
+		return err
+	}
+	translations, err := getTranslation(word, dict)
+	if err != nil {
+		return fmt.Errorf("could not look %s up: %w", word, err)
+	}
+
+	color.New(color.FgYellow, color.Bold).Printf("Word of the day: %s\n", word)
+	setLastResult(word, dict, translations)
+	var buf bytes.Buffer
+	renderTranslation(&buf, translations, dict, word)
+	fmt.Fprint(color.Output, buf.String())
+	return nil
+}
+
+func handleWOTDCommand() error {
+	if currentDict == "" {
+		return fmt.Errorf("no dictionary selected. Use .dict <key> to select one")
+	}
+	return showWordOfTheDay(currentDict)
+}
+
+// showWordOfTheDayOnStartup shows the word of the day if it hasn't been
+// shown yet today.
+func showWordOfTheDayOnStartup() error {
+	if !config.WOTDOnStartup || currentDict == "" {
+		return nil
+	}
+
+	stateFile, err := getStateFile("wotd_last_shown")
+	if err != nil {
+		return err
+	}
+	today := time.Now().Format("2006-01-02")
+	if last, err := os.ReadFile(stateFile); err == nil && strings.TrimSpace(string(last)) == today {
+		return nil
+	}
+
+	if err := showWordOfTheDay(currentDict); err != nil {
+		return err
+	}
+	return os.WriteFile(stateFile, []byte(today+"\n"), 0644)
+}